@@ -0,0 +1,602 @@
+package request
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EncodeError is returned by [request.Decoder.Encode] when a field value cannot be encoded.
+type EncodeError struct {
+	Origin string // parameter location, e.g. "query"
+	Name   string // parameter name, or body format for "body" origin
+	Err    error  // underlying error
+}
+
+func (e EncodeError) Error() string {
+	switch e.Origin {
+	case originQuery:
+		return fmt.Sprintf("encode %s param '%s': %v", e.Origin, e.Name, e.Err)
+	case originBody:
+		return e.Err.Error()
+	default:
+		return fmt.Sprintf("encode %s '%s': %v", e.Origin, e.Name, e.Err)
+	}
+}
+
+func (e EncodeError) Unwrap() error {
+	return e.Err
+}
+
+// Encode builds an HTTP request from a Go struct. It is the inverse of [request.Decode].
+// See [request.Decoder.Encode].
+func Encode(method, urlBase string, i any) (*http.Request, error) {
+	return defaultDecoder.Encode(method, urlBase, i)
+}
+
+// Encode builds an HTTP request from a Go struct using the same field tags as [request.Decoder.Decode],
+// so that a client and a server share one struct definition. Path values replace the wildcards
+// of the base URL, e.g. "{id}" in "https://example.com/users/{id}".
+//
+// Query params are serialized in the style of the field, e.g. "?id=1,2,3" for `query:"id,form"`.
+// Headers, cookies and JSON, XML or text body are set from the corresponding fields.
+// Fields having zero value are omitted, unless the field is a path value, is required or has a default value,
+// e.g. "?limit=0" for `query:"limit,default=10"` so that the decoded value is not the default.
+func (d Decoder) Encode(method, urlBase string, i any) (*http.Request, error) {
+	v := reflect.ValueOf(i)
+	for v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return nil, errors.New("call of Encode passes non-struct as third argument")
+	}
+
+	var (
+		query       = make(url.Values)
+		header      = make(http.Header)
+		cookies     []*http.Cookie
+		body        io.Reader
+		contentType string
+	)
+
+	for _, field := range d.fields(v) {
+		if field.Err != nil {
+			return nil, field.Err
+		}
+
+		conf := field.Conf
+
+		if field.Origin == originMeta || conf.name == "-" {
+			continue
+		}
+
+		// zero value of the field having a default value is encoded, otherwise the default is decoded,
+		// path values are always encoded to fill the wildcards of the base URL
+		if field.Value.IsZero() && !conf.required && conf.defaultValue == nil && field.Origin != originPath {
+			continue
+		}
+
+		var err error
+
+		switch field.Origin {
+		default: // query params
+			err = d.encodeQuery(query, field.Value, conf)
+		case originPath:
+			var value string
+
+			if value, err = d.encodePath(field.Value, conf); err == nil {
+				urlBase = strings.ReplaceAll(urlBase, "{"+conf.name+"}", value)
+			}
+		case originHeader:
+			err = d.encodeHeader(header, field.Value, conf)
+		case originCookie, originCSRF:
+			var value string
+
+			if value, err = d.formatValue(field.Value, conf); err == nil {
+				if field.Origin == originCSRF {
+					header.Set(conf.name, value)
+				} else {
+					cookies = append(cookies, &http.Cookie{Name: conf.name, Value: value})
+				}
+			}
+		case originBody:
+			body, contentType, err = d.encodeBody(field.Value, conf)
+		}
+
+		if err != nil {
+			return nil, EncodeError{Origin: field.Origin, Name: conf.name, Err: err}
+		}
+	}
+
+	if match := pathWildcard.FindString(urlBase); match != "" {
+		return nil, fmt.Errorf("encode path: no field for wildcard %s", match)
+	}
+
+	u, err := url.Parse(urlBase)
+	if err != nil {
+		return nil, fmt.Errorf("parse URL: %w", err)
+	}
+
+	// keep query params of the base URL
+	q := u.Query()
+
+	for k, v := range query {
+		q[k] = append(q[k], v...)
+	}
+
+	u.RawQuery = q.Encode()
+
+	r, err := http.NewRequestWithContext(context.Background(), method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+
+	for k, v := range header {
+		r.Header[k] = v
+	}
+
+	for _, cookie := range cookies {
+		r.AddCookie(cookie)
+	}
+
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+
+	return r, nil
+}
+
+func (d Decoder) encodeBody(rv reflect.Value, conf fieldConf) (io.Reader, string, error) {
+	var (
+		b           []byte
+		contentType string
+		err         error
+	)
+
+	switch format := conf.name; format {
+	default:
+		// registered body decoders have no encoders, e.g. "yaml"
+		return nil, "", fmt.Errorf(`want "json", "xml" or "text", got unsupported "%s"`, format)
+	case "", "json":
+		b, err = json.Marshal(rv.Interface())
+		if err == nil && conf.bodyPath != "" {
+			b, err = wrapJSONBodyPath(b, conf.bodyPath)
+		}
+
+		contentType = "application/json"
+	case "xml":
+		b, err = xml.Marshal(rv.Interface())
+		contentType = "application/xml"
+	case "text":
+		var s string
+
+		if s, err = d.formatValue(rv, fieldConf{}); err == nil {
+			b = []byte(s)
+		}
+
+		contentType = "text/plain; charset=utf-8"
+	}
+
+	if err != nil {
+		return nil, "", fmt.Errorf("encode body: %w", err)
+	}
+
+	return bytes.NewReader(b), contentType, nil
+}
+
+// wrapJSONBodyPath wraps the JSON value in the nested objects of the body path,
+// e.g. {"data":{"attributes":{...}}} for the "data.attributes" path. It is the inverse of jsonBodyPath.
+func wrapJSONBodyPath(b []byte, path string) ([]byte, error) {
+	keys := strings.Split(path, ".")
+
+	for i := len(keys) - 1; i >= 0; i-- {
+		var err error
+
+		if b, err = json.Marshal(map[string]json.RawMessage{keys[i]: b}); err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+	}
+
+	return b, nil
+}
+
+// encodePath serializes the path value in the path style of the field.
+func (d Decoder) encodePath(rv reflect.Value, conf fieldConf) (string, error) {
+	values, err := d.formatValues(rv, conf)
+	if err != nil {
+		return "", err
+	}
+
+	for i, v := range values {
+		values[i] = url.PathEscape(v)
+	}
+
+	switch conf.style {
+	default:
+		return strings.Join(values, ","), nil
+	case PathStyleLabel:
+		if conf.exploded {
+			return "." + strings.Join(values, "."), nil
+		}
+
+		return "." + strings.Join(values, ","), nil
+	case PathStyleMatrix:
+		if conf.exploded {
+			return ";" + conf.name + "=" + strings.Join(values, ";"+conf.name+"="), nil
+		}
+
+		return ";" + conf.name + "=" + strings.Join(values, ","), nil
+	}
+}
+
+// encodeQuery adds the query params of the field serialized in the field style.
+func (d Decoder) encodeQuery(query url.Values, rv reflect.Value, conf fieldConf) error {
+	if conf.raw {
+		return nil
+	}
+
+	rv = reflect.Indirect(rv)
+
+	switch {
+	case conf.style == QueryStyleDeepObject, conf.brackets:
+		return d.encodeDeepObject(query, conf.name, rv)
+	case conf.kvlist:
+		entrySep, pairSep := conf.kvSeparators(d.query)
+		entries := make([]string, 0, rv.Len())
+
+		for _, key := range sortedKeys(rv) {
+			k, err := d.formatValue(key, fieldConf{})
+			if err != nil {
+				return err
+			}
+
+			v, err := d.formatValue(rv.MapIndex(key), fieldConf{})
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, k+pairSep+v)
+		}
+
+		query.Set(conf.name, strings.Join(entries, entrySep))
+
+		return nil
+	case conf.base64json:
+		b, err := json.Marshal(rv.Interface())
+		if err != nil {
+			return fmt.Errorf("encode JSON: %w", err)
+		}
+
+		query.Set(conf.name, base64.RawURLEncoding.EncodeToString(b))
+
+		return nil
+	case conf.positional:
+		values := make([]string, 0, rv.NumField())
+
+		for i := range rv.NumField() {
+			v, err := d.formatValue(rv.Field(i), conf)
+			if err != nil {
+				return err
+			}
+
+			values = append(values, v)
+		}
+
+		query.Set(conf.name, strings.Join(values, ","))
+
+		return nil
+	case d.isDeepObject(rv.Type()):
+		values, err := d.formatFormObject(rv)
+		if err != nil {
+			return err
+		}
+
+		if len(values) > 0 {
+			query.Set(conf.name, strings.Join(values, conf.valueDelimiter()))
+		}
+
+		return nil
+	}
+
+	values, err := d.formatValues(rv, conf)
+	if err != nil {
+		return err
+	}
+
+	if conf.exploded {
+		query[conf.name] = append(query[conf.name], values...)
+	} else {
+		query.Set(conf.name, strings.Join(values, conf.valueDelimiter()))
+	}
+
+	return nil
+}
+
+// encodeDeepObject adds the query params of the struct or the map properties, e.g. "?filter[status]=open".
+func (d Decoder) encodeDeepObject(query url.Values, name string, rv reflect.Value) error {
+	rv = reflect.Indirect(rv)
+
+	switch {
+	default:
+		values, err := d.formatValues(rv, fieldConf{})
+		if err != nil {
+			return err
+		}
+
+		if isSlice(rv.Type()) {
+			name += "[]"
+		}
+
+		query[name] = append(query[name], values...)
+	case d.isDeepObject(rv.Type()) && rv.Kind() == reflect.Map:
+		for _, key := range sortedKeys(rv) {
+			k, err := d.formatValue(key, fieldConf{})
+			if err != nil {
+				return err
+			}
+
+			if err := d.encodeDeepObject(query, name+"["+k+"]", rv.MapIndex(key)); err != nil {
+				return err
+			}
+		}
+	case d.isDeepObject(rv.Type()):
+		for i := range rv.NumField() {
+			sft := rv.Type().Field(i)
+			if !sft.IsExported() || rv.Field(i).IsZero() {
+				continue
+			}
+
+			conf, err := d.deepPropertyConf(sft)
+			if err != nil {
+				return err
+			}
+
+			if err := d.encodeDeepObject(query, name+"["+conf.name+"]", rv.Field(i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatFormObject returns the alternating properties and values of the struct or the map,
+// e.g. [R 100 G 200] serialized as "?color=R,100,G,200".
+func (d Decoder) formatFormObject(rv reflect.Value) ([]string, error) {
+	var values []string
+
+	if rv.Kind() == reflect.Map {
+		for _, key := range sortedKeys(rv) {
+			k, err := d.formatValue(key, fieldConf{})
+			if err != nil {
+				return nil, err
+			}
+
+			v, err := d.formatValue(rv.MapIndex(key), fieldConf{})
+			if err != nil {
+				return nil, err
+			}
+
+			values = append(values, k, v)
+		}
+
+		return values, nil
+	}
+
+	for i := range rv.NumField() {
+		sft := rv.Type().Field(i)
+		if !sft.IsExported() || rv.Field(i).IsZero() {
+			continue
+		}
+
+		conf, err := d.deepPropertyConf(sft)
+		if err != nil {
+			return nil, err
+		}
+
+		v, err := d.formatValue(rv.Field(i), conf)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, conf.name, v)
+	}
+
+	return values, nil
+}
+
+// encodeHeader sets the header values of the field.
+func (d Decoder) encodeHeader(header http.Header, rv reflect.Value, conf fieldConf) error {
+	if conf.prefix {
+		rv = reflect.Indirect(rv)
+
+		for _, key := range sortedKeys(rv) {
+			k, err := d.formatValue(key, fieldConf{})
+			if err != nil {
+				return err
+			}
+
+			values, err := d.formatValues(rv.MapIndex(key), conf)
+			if err != nil {
+				return err
+			}
+
+			header[http.CanonicalHeaderKey(conf.name+k)] = values
+		}
+
+		return nil
+	}
+
+	values, err := d.formatValues(rv, conf)
+	if err != nil {
+		return err
+	}
+
+	header.Set(conf.name, strings.Join(values, ", "))
+
+	return nil
+}
+
+// sortedKeys returns the map keys sorted by their string representation.
+func sortedKeys(rv reflect.Value) []reflect.Value {
+	keys := rv.MapKeys()
+
+	slices.SortFunc(keys, func(a, b reflect.Value) int {
+		return strings.Compare(fmt.Sprint(a.Interface()), fmt.Sprint(b.Interface()))
+	})
+
+	return keys
+}
+
+// formatValues formats the value, or each element of the slice.
+func (d Decoder) formatValues(rv reflect.Value, conf fieldConf) ([]string, error) {
+	rv = reflect.Indirect(rv)
+
+	if flags, ok := d.flags[rv.Type()]; ok {
+		return formatFlags(rv, flags), nil
+	}
+
+	if !isSlice(rv.Type()) {
+		v, err := d.formatValue(rv, conf)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{v}, nil
+	}
+
+	values := make([]string, 0, rv.Len())
+
+	for i := range rv.Len() {
+		v, err := d.formatValue(rv.Index(i), conf)
+		if err != nil {
+			return nil, err
+		}
+
+		values = append(values, v)
+	}
+
+	return values, nil
+}
+
+// formatValue formats the value as decoded by [request.Decoder.setValue].
+func (d Decoder) formatValue(rv reflect.Value, conf fieldConf) (string, error) {
+	for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return "", nil
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Type() == timeType {
+		return d.formatTime(rv.Interface().(time.Time), conf.format), nil //nolint:forcetypeassert
+	}
+
+	m, ok := rv.Interface().(encoding.TextMarshaler)
+	if !ok && rv.CanAddr() {
+		m, ok = rv.Addr().Interface().(encoding.TextMarshaler)
+	}
+
+	if ok {
+		b, err := m.MarshalText()
+		if err != nil {
+			return "", fmt.Errorf("marshal text: %w", err)
+		}
+
+		return string(b), nil
+	}
+
+	switch kind := rv.Kind(); kind { //nolint:exhaustive
+	default:
+		return "", UnsupportedTypeError{Kind: kind}
+	case reflect.Bool:
+		return strconv.FormatBool(rv.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), formatBase(conf)), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), formatBase(conf)), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'f', -1, rv.Type().Bits()), nil
+	case reflect.String:
+		if conf.base64 {
+			return base64.RawURLEncoding.EncodeToString([]byte(rv.String())), nil
+		}
+
+		return rv.String(), nil
+	case reflect.Slice:
+		// slice of bytes
+		if conf.base64 {
+			return base64.RawURLEncoding.EncodeToString(rv.Bytes()), nil
+		}
+
+		return string(rv.Bytes()), nil
+	}
+}
+
+// formatBase returns the base of formatted integers, decimal for the base detected by the prefix.
+func formatBase(conf fieldConf) int {
+	if base := conf.intBase(); base != 0 {
+		return base
+	}
+
+	return decimalBase
+}
+
+// formatTime formats the time using the first layout of the format or the decoder time layouts.
+func (d Decoder) formatTime(t time.Time, format string) string {
+	layout := time.RFC3339
+
+	if format != "" {
+		layout, _, _ = strings.Cut(format, "|")
+	} else if len(d.timeLayouts) > 0 {
+		layout = d.timeLayouts[0]
+	}
+
+	if layout == timeFormatUnix {
+		return strconv.FormatInt(t.Unix(), 10)
+	}
+
+	if named, ok := timeFormats[layout]; ok {
+		layout = named
+	}
+
+	return t.Format(layout)
+}
+
+// formatFlags returns the sorted names of the bits set in the value.
+func formatFlags(rv reflect.Value, flags map[string]uint) []string {
+	var bits uint
+
+	if rv.CanInt() {
+		bits = uint(rv.Int()) //nolint:gosec
+	} else {
+		bits = uint(rv.Uint())
+	}
+
+	var names []string
+
+	for name, bit := range flags {
+		if bit != 0 && bits&bit == bit {
+			names = append(names, name)
+		}
+	}
+
+	slices.Sort(names)
+
+	return names
+}
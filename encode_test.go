@@ -0,0 +1,224 @@
+package request
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEncode(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		ID     int       `path:"id"`
+		Tags   []string  `query:"tags,form"`
+		Labels []string  `query:"label"`
+		Codes  []string  `query:"codes,delimiter=::"`
+		Token  []byte    `query:"token,base64"`
+		Limit  int       `query:"limit"`
+		Since  time.Time `query:"since,format=date"`
+		Offset *int      `query:"offset"`
+		Filter struct {
+			Status string
+			UserID int `json:"user_id"`
+		} `query:"filter,deepObject"`
+		Env       map[string]string `query:"env,kvlist"`
+		RequestID string            `header:"X-Request-Id"`
+		Session   string            `cookie:"session"`
+		Body      struct {
+			Name string `json:"name"`
+		} `body:"json"`
+	}
+
+	want := Req{
+		ID:        7,
+		Tags:      []string{"a", "b"},
+		Labels:    []string{"x", "y"},
+		Codes:     []string{"a", "b"},
+		Token:     []byte{1, 2, 255},
+		Limit:     10,
+		Since:     time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC),
+		Env:       map[string]string{"team": "core", "tier": "1"},
+		RequestID: "8a2f",
+		Session:   "s1",
+	}
+	want.Filter.Status = "open"
+	want.Filter.UserID = 3
+	want.Body.Name = "alex"
+
+	r, err := Encode(http.MethodPost, "https://example.com/users/{id}", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantQuery := "codes=a%3A%3Ab&env=team%3Acore%2Ctier%3A1&filter%5Bstatus%5D=open&filter%5Buser_id%5D=3&" +
+		"label=x&label=y&limit=10&since=2024-01-31&tags=a%2Cb&token=AQL_"
+	if r.URL.Path != "/users/7" || r.URL.RawQuery != wantQuery {
+		t.Errorf("want /users/7?%s, got %s?%s", wantQuery, r.URL.Path, r.URL.RawQuery)
+	}
+
+	// round trip
+	var got Req
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := Decode(r, &got); err != nil {
+			t.Error(err)
+		}
+	})
+
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestEncodePathStyles(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Label  []int `path:"label,label"`
+		Matrix []int `path:"matrix,matrix,explode"`
+	}
+
+	req.Label = []int{1, 2}
+	req.Matrix = []int{3, 4}
+
+	r, err := Encode(http.MethodGet, "/{label}/{matrix}", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "/.1,2/;matrix=3;matrix=4"; r.URL.Path != want {
+		t.Errorf(`want "%s", got "%s"`, want, r.URL.Path)
+	}
+}
+
+func TestEncodeBodyPath(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		Body struct {
+			Name string `json:"name"`
+		} `oas:"data.attributes,body,json"`
+	}
+
+	var want Req
+	want.Body.Name = "alex"
+
+	r, err := Encode(http.MethodPost, "/", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wantBody := `{"data":{"attributes":{"name":"alex"}}}`; string(b) != wantBody {
+		t.Errorf(`want "%s", got "%s"`, wantBody, b)
+	}
+
+	// round trip
+	r.Body = io.NopCloser(bytes.NewReader(b))
+
+	var got Req
+
+	if err := Decode(r, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if want != got {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
+
+func TestEncodeError(t *testing.T) {
+	t.Parallel()
+
+	req := struct {
+		C complex64 `query:"c"`
+	}{C: 1}
+
+	_, err := Encode(http.MethodGet, "/", req)
+
+	var encodeErr EncodeError
+	if !errors.As(err, &encodeErr) || !errors.As(err, new(UnsupportedTypeError)) {
+		t.Fatalf("want EncodeError, got %v", err)
+	}
+
+	if want := "encode query param 'c': unknown type: complex64"; err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+
+	// body format without encoder
+	body := struct {
+		Body map[string]string `body:"yaml"`
+	}{Body: map[string]string{"name": "alex"}}
+
+	_, err = Encode(http.MethodPost, "/", body)
+	if want := `want "json", "xml" or "text", got unsupported "yaml"`; err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%v"`, want, err)
+	}
+}
+
+func TestEncodeZeroPath(t *testing.T) {
+	t.Parallel()
+
+	req := struct {
+		ID int `path:"id"`
+	}{}
+
+	r, err := Encode(http.MethodGet, "/users/{id}", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "/users/0"; r.URL.Path != want {
+		t.Errorf(`want "%s", got "%s"`, want, r.URL.Path)
+	}
+
+	// wildcard without field
+	if _, err := Encode(http.MethodGet, "/users/{id}/{slug}", req); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestEncodeZeroDefault(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		Limit  int    `query:"limit,default=10"`
+		Sort   string `query:"sort,default=name"`
+		Offset int    `query:"offset"`
+	}
+
+	var want Req
+
+	r, err := Encode(http.MethodGet, "/", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wantQuery := "limit=0&sort="; r.URL.RawQuery != wantQuery {
+		t.Errorf(`want "%s", got "%s"`, wantQuery, r.URL.RawQuery)
+	}
+
+	// round trip
+	var got Req
+
+	if err := Decode(r, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if want != got {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+}
@@ -2,6 +2,7 @@ package request_test
 
 import (
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -35,6 +36,43 @@ func ExampleDecode() {
 	// {FilterType:[pending approved] FilterClientIDs:[1 2 3] ClientID:4 Client:{ID:1}}
 }
 
+func ExampleBodyDecoder() {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("id:1"))
+	r.Header.Set("Content-Type", "application/x-protobuf")
+
+	// Message mimics a generated protobuf message, e.g. use proto.Unmarshal(b, v.(proto.Message))
+	// to decode protobuf message.
+	type Message struct {
+		ID string
+	}
+
+	dec := request.NewDecoder(request.BodyDecoder("protobuf", func(r io.Reader, v any) error {
+		m, ok := v.(*Message)
+		if !ok {
+			return fmt.Errorf("want message, got %T", v)
+		}
+
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("read body: %w", err)
+		}
+
+		m.ID = strings.TrimPrefix(string(b), "id:")
+
+		return nil
+	}, "application/x-protobuf"))
+
+	var req struct {
+		Message Message `body:""`
+	}
+
+	_ = dec.Decode(r, &req)
+
+	fmt.Printf("%+v\n", req)
+	// Output:
+	// {Message:{ID:1}}
+}
+
 func ExampleDecoder_Decode() {
 	r := httptest.NewRequest(http.MethodPost, "/?ids=1,2,3", nil)
 
@@ -1,3 +1,3 @@
 module go.expect.digital/request
 
-go 1.22
+go 1.23
@@ -0,0 +1,51 @@
+package request
+
+import (
+	"context"
+	"net/http"
+)
+
+// contextKey is the context key of the value of type T decoded by [request.Middleware].
+type contextKey[T any] struct{}
+
+// Middleware returns a middleware decoding the request into a value of type T, see [request.DecodeTo].
+// The decoded value is stored in the request context and read with [request.FromContext]:
+//
+//	type GetUserRequest struct {
+//		ID int `path:"id"`
+//	}
+//
+//	mux.Handle("GET /users/{id}", request.Middleware[GetUserRequest]()(http.HandlerFunc(getUser)))
+//
+//	func getUser(w http.ResponseWriter, r *http.Request) {
+//		req, _ := request.FromContext[GetUserRequest](r.Context())
+//	}
+//
+// On decoding error, the middleware responds with the status code of the error (see [request.StatusCode])
+// and the error message, the next handler is not called.
+func Middleware[T any](opts ...Opt) func(next http.Handler) http.Handler {
+	d := defaultDecoder
+	if len(opts) > 0 {
+		d = NewDecoder(opts...)
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			v, err := DecodeWith[T](d, r)
+			if err != nil {
+				http.Error(w, err.Error(), StatusCode(err))
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey[T]{}, v)))
+		})
+	}
+}
+
+// FromContext returns the value of type T decoded by [request.Middleware]
+// and reports whether the value is present in the context.
+func FromContext[T any](ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(contextKey[T]{}).(T)
+
+	return v, ok
+}
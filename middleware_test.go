@@ -0,0 +1,54 @@
+package request
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		ID    int `path:"id"`
+		Limit int `query:"limit,max=100"`
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /users/{id}", Middleware[Req](TrimSpace())(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req, ok := FromContext[Req](r.Context())
+			if !ok {
+				t.Error("want decoded request in context")
+			}
+
+			if want := (Req{ID: 7, Limit: 10}); want != req {
+				t.Errorf("want %+v, got %+v", want, req)
+			}
+
+			w.WriteHeader(http.StatusNoContent)
+		}),
+	))
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/7?limit=%2010", nil))
+
+	if w.Code != http.StatusNoContent {
+		t.Errorf("want %d, got %d", http.StatusNoContent, w.Code)
+	}
+
+	// invalid
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/7?limit=1000", nil))
+
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "query param 'limit'") {
+		t.Errorf("want %d with error, got %d %s", http.StatusBadRequest, w.Code, w.Body)
+	}
+
+	// absent
+	if _, ok := FromContext[Req](context.Background()); ok {
+		t.Error("want no value in context")
+	}
+}
@@ -5,11 +5,11 @@
 // e.g. OpenAPI spec to a server code in Golang. However, it's not always possible due to certain constraints.
 //
 // Key Features:
-//   - Decodes path parameters, query parameters, request headers (not yet implemented), and request body.
+//   - Decodes path parameters, query parameters, request headers, cookies, and request body.
 //   - Supports different query parameter styles: form, space-delimited, pipe-delimited,
 //     and deep (nested) objects.
 //   - Allows customization of field names, required parameters, and decoding behavior through struct tags.
-//   - Handles different body content types (JSON, XML) based on the Accept header or a specified field tag.
+//   - Handles different body content types (JSON, XML) based on the Content-Type header or a specified field tag.
 //
 // When using Go standard packages, the code might look something like:
 //
@@ -51,36 +51,354 @@
 package request
 
 import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
 	"encoding"
+	"encoding/base64"
+	"encoding/csv"
 	"encoding/json"
 	"encoding/xml"
 	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"math"
+	"mime"
+	"mime/multipart"
 	"net/http"
+	"net/url"
 	"reflect"
+	"regexp"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
 )
 
 // List of supported serialization styles.
 const (
-	QueryStyleForm           = "form"           // imploded "?id=3,4,5" or exploded "?id=3&id=4&id=5"
-	QueryStyleSpaceDelimited = "spaceDelimited" // imploded "?id=3%204%205" or exploded "?id=3&id=4&id=5"
-	QueryStylePipeDelimited  = "pipeDelimited"  // imploded "?id=3|4|5" or exploded "?id=3&id=4&=5"
-	QueryStyleDeepObject     = "deepObject"     // exploded "?id[role]=admin&id[firstName]=Alex"
+	QueryStyleForm               = "form"               // imploded "?id=3,4,5" or exploded "?id=3&id=4&id=5"
+	QueryStyleSpaceDelimited     = "spaceDelimited"     // imploded "?id=3%204%205" or exploded "?id=3&id=4&id=5"
+	QueryStylePipeDelimited      = "pipeDelimited"      // imploded "?id=3|4|5" or exploded "?id=3&id=4&=5"
+	QueryStyleSemicolonDelimited = "semicolonDelimited" // imploded "?id=3;4;5" or exploded "?id=3&id=4&id=5"
+	QueryStyleDeepObject         = "deepObject"         // exploded "?id[role]=admin&id[firstName]=Alex"
 )
 
+// defaultTagName is the default name of the combined field tag, see [request.TagName].
+const defaultTagName = "oas"
+
+// List of supported path parameter serialization styles.
+const (
+	PathStyleSimple = "simple" // "/users/3,4,5"
+	PathStyleLabel  = "label"  // imploded "/users/.3,4,5" or exploded "/users/.3.4.5"
+	PathStyleMatrix = "matrix" // imploded "/users/;id=3,4,5" or exploded "/users/;id=3;id=4;id=5"
+)
+
+// List of parameter origins.
+const (
+	originPath   = "path"
+	originQuery  = "query"
+	originHeader = "header"
+	originCookie = "cookie"
+	originCSRF   = "csrf"
+	originMeta   = "meta"
+	originBody   = "body"
+)
+
+// origins are the parameter origins of the combined field tag.
+var origins = []string{originPath, originQuery, originHeader, originCookie, originBody, originCSRF, originMeta}
+
+// ErrUnsupportedMediaType is returned when the request body media type is not allowed.
+// Match [request.UnsupportedMediaTypeError] with [errors.Is] and ErrUnsupportedMediaType.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
+// ErrBodyTooLarge is returned when the request body exceeds the limit set by [request.MaxBodyBytes] option.
+var ErrBodyTooLarge = errors.New("request body too large")
+
+// ErrUnknownField is returned when the JSON body contains a field not present in the target
+// and [request.DisallowUnknownBodyFields] option is set.
+var ErrUnknownField = errors.New("unknown field")
+
+// ErrInvalidCSRFToken is returned when [request.CSRFValidator] rejects the CSRF token.
+var ErrInvalidCSRFToken = errors.New("invalid CSRF token")
+
+// errEmptyBody is returned when the request body is empty or absent.
+var errEmptyBody = errors.New("empty body")
+
+// RequiredError is returned when a required parameter is not present in the request.
+type RequiredError struct {
+	Origin string // parameter location, e.g. "query"
+	Name   string // parameter name, "body" for the request body
+}
+
+func (e RequiredError) Error() string {
+	if e.Origin == originBody {
+		return "body is required"
+	}
+
+	if e.Origin == originHeader || e.Origin == originCookie {
+		return fmt.Sprintf("%s '%s' is required", e.Origin, e.Name)
+	}
+
+	return fmt.Sprintf("%s param '%s' is required", e.Origin, e.Name)
+}
+
+// DecodeError is returned when a parameter is present in the request but cannot be decoded.
+type DecodeError struct {
+	Origin string // parameter location, e.g. "query"
+	Name   string // parameter name, or body format for "body" origin
+	Err    error  // underlying error
+}
+
+func (e DecodeError) Error() string {
+	switch e.Origin {
+	case originQuery:
+		return fmt.Sprintf("%s param '%s': %v", e.Origin, e.Name, e.Err)
+	case originBody:
+		return e.Err.Error()
+	default:
+		return fmt.Sprintf("%s '%s': %v", e.Origin, e.Name, e.Err)
+	}
+}
+
+func (e DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedTypeError is returned when the field type cannot be decoded from a string value.
+type UnsupportedTypeError struct {
+	Kind reflect.Kind
+}
+
+func (e UnsupportedTypeError) Error() string {
+	return fmt.Sprintf("unknown type: %s", e.Kind)
+}
+
+// UnsupportedMediaTypeError is returned when the request body media type is not allowed
+// (see [request.AllowContentTypes]), or the body format cannot be determined by the media type.
+type UnsupportedMediaTypeError struct {
+	MediaType string // the value of the "Content-Type" request header
+}
+
+func (e UnsupportedMediaTypeError) Error() string {
+	return fmt.Sprintf(`%s "%s"`, ErrUnsupportedMediaType, e.MediaType)
+}
+
+// Is reports whether the target is [request.ErrUnsupportedMediaType].
+func (e UnsupportedMediaTypeError) Is(target error) bool {
+	return target == ErrUnsupportedMediaType //nolint:errorlint
+}
+
+// EnumError is returned when a parameter value is not one of the allowed values
+// specified in the field tag, e.g. `query:"status,enum=open|closed"`.
+type EnumError struct {
+	Name    string   // parameter name
+	Value   string   // the value not allowed
+	Allowed []string // allowed values
+}
+
+func (e EnumError) Error() string {
+	return fmt.Sprintf("value '%s' is not one of %v", e.Value, e.Allowed)
+}
+
+// PatternError is returned when a parameter value does not match the regular expression
+// specified in the field tag, e.g. `query:"code,pattern=^[A-Z]{3}$"`.
+type PatternError struct {
+	Name    string // parameter name
+	Value   string // the value not matching the pattern
+	Pattern string // regular expression
+}
+
+func (e PatternError) Error() string {
+	return fmt.Sprintf("value '%s' does not match pattern '%s'", e.Value, e.Pattern)
+}
+
+// RangeError is returned when a numeric parameter value is out of the bounds specified
+// in the field tag, e.g. `query:"limit,min=1,max=100"`. The unspecified bound is infinite.
+type RangeError struct {
+	Name     string  // parameter name
+	Value    float64 // the value out of range
+	Min, Max float64 // inclusive bounds
+}
+
+func (e RangeError) Error() string {
+	if e.Value < e.Min {
+		return fmt.Sprintf("value %g is less than minimum %g", e.Value, e.Min)
+	}
+
+	return fmt.Sprintf("value %g is greater than maximum %g", e.Value, e.Max)
+}
+
+// ArrayLenError is returned when a parameter has more values than the length of the array field,
+// e.g. "?point=1,2,3,4" for [3]int.
+type ArrayLenError struct {
+	Name string // parameter name
+	Len  int    // length of the array
+	Got  int    // number of values
+}
+
+func (e ArrayLenError) Error() string {
+	return fmt.Sprintf("want at most %d values, got %d", e.Len, e.Got)
+}
+
+// UnknownParamError is returned when the request has query params not decoded into any field
+// and [request.DisallowUnknownQuery] option is set.
+type UnknownParamError struct {
+	Names []string // sorted names of the unknown query params
+}
+
+func (e UnknownParamError) Error() string {
+	if len(e.Names) == 1 {
+		return fmt.Sprintf("unknown query param '%s'", e.Names[0])
+	}
+
+	return fmt.Sprintf("unknown query params '%s'", strings.Join(e.Names, "', '"))
+}
+
+// Validator is implemented by the targets validating themselves after decoding, e.g. cross-field invariants:
+//
+//	func (r ListRequest) Validate() error {
+//		if r.End.Before(r.Start) {
+//			return errors.New("end is before start")
+//		}
+//
+//		return nil
+//	}
+//
+// Validate is called after all fields are decoded without errors.
+type Validator interface {
+	Validate() error
+}
+
+// ValidationError is returned when [request.Validator.Validate] of the target returns an error.
+type ValidationError struct {
+	Err error // error of Validate
+}
+
+func (e ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Errors contains all field errors of a decoding with [request.CollectErrors] option.
+type Errors []error
+
+func (e Errors) Error() string {
+	return errors.Join(e...).Error()
+}
+
+// Unwrap returns the field errors. It allows matching any of the field errors using [errors.Is] and [errors.As].
+func (e Errors) Unwrap() []error {
+	return e
+}
+
+// Missing returns the names of all required parameters not present in the request.
+func (e Errors) Missing() []string {
+	var missing []string
+
+	for _, err := range e {
+		var required RequiredError
+		if errors.As(err, &required) {
+			missing = append(missing, required.Name)
+		}
+	}
+
+	return missing
+}
+
+// StatusCode returns the HTTP status code to respond with for the decoding error:
+//   - [http.StatusOK] if err is nil.
+//   - [http.StatusRequestEntityTooLarge] for [request.ErrBodyTooLarge].
+//   - [http.StatusUnsupportedMediaType] for [request.ErrUnsupportedMediaType].
+//   - [http.StatusForbidden] for [request.ErrInvalidCSRFToken].
+//   - [http.StatusInternalServerError] for [request.UnsupportedTypeError], the field type cannot be decoded.
+//   - [http.StatusBadRequest] otherwise, e.g. [request.RequiredError], [request.DecodeError],
+//     [request.EnumError], [request.RangeError], [request.ValidationError] or an error of [request.Rules].
+//
+// If err contains several errors (see [request.CollectErrors]), the first matching status code
+// in the order above is returned.
+func StatusCode(err error) int {
+	var unsupportedErr UnsupportedTypeError
+
+	switch {
+	case err == nil:
+		return http.StatusOK
+	case errors.Is(err, ErrBodyTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, ErrUnsupportedMediaType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, ErrInvalidCSRFToken):
+		return http.StatusForbidden
+	case errors.As(err, &unsupportedErr):
+		return http.StatusInternalServerError
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+type bodyDecoder struct {
+	decode     func(r io.Reader, v any) error
+	mediaTypes []string
+}
+
 type queryConf struct {
 	// one of QueryStyleForm, QueryStyleSpace, QueryStylePipe or QueryStyleDeep
 	style string
 	// true - "?id=1&id=2&id=3", false - "?id=1,2,3"
 	exploded bool
+	// separator between entries in a key-value list, e.g. "," in "?labels=env:prod,team:core"
+	kvEntrySep string
+	// separator between key and value in a key-value list, e.g. ":" in "?labels=env:prod"
+	kvPairSep string
 }
 
 // Decoder decodes (binds) [net/http.Request] data into Go struct.
 type Decoder struct {
-	pathValue func(r *http.Request, name string) string
-	query     queryConf
+	pathValue            func(r *http.Request, name string) string
+	pathSplitter         func(raw string) []string
+	pathStyle            string
+	tagName              string    // combined field tag name
+	plans                *sync.Map // decoding plans by struct type
+	onDecodeDuration     func(d time.Duration)
+	onUnknownQuery       func(keys []string)
+	flags                map[reflect.Type]map[string]uint
+	decoders             map[reflect.Type]func(ctx context.Context, value string) (any, error)
+	timeLayouts          []string
+	bodyDecoders         map[string]bodyDecoder
+	yamlUnmarshal        func(data []byte, v any) error
+	xmlCharsetReader     func(charset string, input io.Reader) (io.Reader, error)
+	xmlNonStrict         bool
+	xmlDefaultSpace      string
+	query                queryConf
+	origins              []string
+	contentTypes         []string
+	multipartMaxMemory   int64
+	maxBodyBytes         int64
+	csrfValidator        func(r *http.Request, token string) error
+	rules                []func(presence map[string]bool) error
+	collectErrors        bool
+	rejectMultiForScalar bool
+	useNumber            bool
+	disallowUnknown      bool // whether unknown JSON body fields are rejected
+	decompressBody       bool // whether the body is decompressed by Content-Encoding
+	disallowUnknownQuery bool
+	caseSensitiveQuery   bool
+	trimSpace            bool     // whether leading and trailing spaces of values are removed
+	lenientArrays        bool     // whether a single value of exploded slice is split by the style delimiter
+	boolTrue, boolFalse  []string // additional boolean literals
+	discriminators       map[reflect.Type]func(data json.RawMessage) (any, error)
+	// ctx is the context of a single decoding passed to the registered decoders
+	ctx context.Context //nolint:containedctx
 }
 
 // Opt allows to override default [request.Decoder] options.
@@ -107,10 +425,56 @@ func PathValue(pathValue func(r *http.Request, name string) string) Opt { //noli
 	})
 }
 
+// PathVars overrides the path parameter getter in [request.NewDecoder] with a function returning
+// all path parameters of the request, e.g. [github.com/gorilla/mux.Vars]:
+//
+//	decoder := request.NewDecoder(request.PathVars(mux.Vars))
+//
+// The getter of a single path parameter is set with [request.PathValue], e.g.
+// request.PathValue(chi.URLParam) for [github.com/go-chi/chi/v5.URLParam].
+//
+// [github.com/gorilla/mux.Vars]: https://pkg.go.dev/github.com/gorilla/mux#Vars
+// [github.com/go-chi/chi/v5.URLParam]: https://pkg.go.dev/github.com/go-chi/chi/v5#URLParam
+func PathVars(vars func(r *http.Request) map[string]string) Opt { //nolint:ireturn
+	return PathValue(func(r *http.Request, name string) string {
+		return vars(r)[name]
+	})
+}
+
+// PathSplitter allows to override default splitting of a path value into multiple values
+// for slice fields in [request.NewDecoder].
+func PathSplitter(pathSplitter func(raw string) []string) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.pathSplitter = pathSplitter
+	})
+}
+
+// TagName overrides the name of the combined field tag, "oas" by default. The combined field tag
+// specifies the parameter name, origin and settings, e.g. `oas:"id,query,form,required"` is equivalent
+// to `query:"id,form,required"`. The origin defaults to query, e.g. `oas:"id"`.
+func TagName(name string) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.tagName = name
+	})
+}
+
+// PathStyle allows to override default path parameter style:
+//   - [request.PathStyleSimple]
+//   - [request.PathStyleLabel]
+//   - [request.PathStyleMatrix]
+//
+// The style is overridden per field in the field tag, e.g. `path:"id,matrix,explode"`.
+func PathStyle(style string) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.pathStyle = style
+	})
+}
+
 // QueryStyle allows to override default query parameter style:
 //   - [request.QueryStyleForm]
 //   - [request.QueryStyleSpaceDelimited]
 //   - [request.QueryStylePipeDelimited]
+//   - [request.QueryStyleSemicolonDelimited]
 //   - [request.QueryStyleDeepObject]
 func QueryStyle(style string) Opt { //nolint:ireturn
 	return newOpt(func(d *Decoder) {
@@ -133,399 +497,3770 @@ func QueryImplode() Opt { //nolint:ireturn
 	})
 }
 
-// NewDecoder returns a new decoder to decode [net/http.Request] data into Go struct.
-//
-// By default:
-//   - the decoder reads path value using
-//     https://pkg.go.dev/net/http#Request.PathValue. Override with [request.PathValue] option.
-//   - the decoder uses exploded query parameters. Override with [request.QueryImplode]
-//     or [request.QueryExplode] option.
-//   - the decoder uses [request.QueryStyleForm] query parameter style. Override with [request.QueryStyle] option.
-func NewDecoder(opts ...Opt) Decoder {
-	decoder := Decoder{
-		pathValue: func(r *http.Request, name string) string { return r.PathValue(name) },
-		query: queryConf{
-			exploded: true,
-			style:    QueryStyleForm,
-		},
-	}
-
-	for _, opt := range opts {
-		opt.apply(&decoder)
-	}
-
-	return decoder
+// QueryKVListSeparators overrides the separators used to decode key-value list query parameters
+// (fields having "kvlist" in the field tag). The entry separator splits the list into entries,
+// the pair separator splits an entry into a key and a value. The separators of a field
+// are set in the field tag, e.g. `query:"labels,entrySep=!,pairSep=="`.
+func QueryKVListSeparators(entry, pair string) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.query.kvEntrySep = entry
+		d.query.kvPairSep = pair
+	})
 }
 
-var defaultDecoder = NewDecoder()
+// RegisterFlags registers named bits of the integer type t. The values of the
+// type t are decoded by combining the bits of all tokens with bitwise OR,
+// e.g. "?perms=read,write" with the field tag `query:"perms,form"`.
+// Decoding of an unknown token returns an error.
+func RegisterFlags(t reflect.Type, flags map[string]uint) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		if d.flags == nil {
+			d.flags = make(map[reflect.Type]map[string]uint)
+		}
 
-// Decode decodes an HTTP request into a Go struct according to OpenAPI 3 specification.
-func Decode(r *http.Request, i interface{}) error {
-	return defaultDecoder.Decode(r, i)
+		d.flags[t] = maps.Clone(flags)
+	})
 }
 
-// Decode decodes an HTTP request into Go struct.
-//
-// Decoding of query params conforms to the [Query Serialization] spec.
-//
-//	// required - decoding returns error if query param is not present
-//	var req struct {
-//		Name string `query:",required"`
-//	}
-//
-//	// default - ?id=1&id=2&id=3
-//	var req struct {
-//		Id []int // case insensitive match of field name and query parameter
-//	}
-//
-//	// comma delimited - ?id=1,2,3
-//	var req struct {
-//		Id []int  `query:",form"`      // implicitly imploded
-//		Ids []int `query:"id,imploded` // form by default
-//	}
-//
-//	// pipe delimited - ?id=1|2|3
-//	var req struct {
-//		Id []int `query:",pipe" // implicitly imploded
-//	}
-//
-//	// space delimited - ?id=1%202%203
-//	var req struct {
-//		Id []int `query:",space"` // implicitly imploded
-//	}
-//
-//	// set different name - ?id=1,2,3
-//	var req struct {
-//		FilterClientIds []int `query:"id,form"` // implicitly imploded
-//	}
-//
-// Use [encoding.TextUnmarshaler] to implement custom decoding.
-//
-// Decoding of request headers is NOT yet implemented.
-//
-// Decoding of request body is simple - it uses either json or xml unmarshaller:
-//
-//	type Entity struct {
-//		Id int
-//	}
-//
-//	// If no field tag value specified, "Accept" request header is used to determine decoding. Uses json by default.
-//	var req struct {
-//		Entity `body:""`
-//	}
-//
-//	// Always use JSON umarshalling, ignore "Accept" request header:
-//	var req struct {
-//		Entity `body:"json"`
-//	}
-//
-//	// Always use XML unmarshalling, ignore "Accept" request header:
-//	var req struct {
-//		Entity `body:"xml"`
-//	}
-//
-// [Query Serialization]: https://swagger.io/docs/specification/serialization/#query
-func (d Decoder) Decode(r *http.Request, i interface{}) error {
-	v := reflect.ValueOf(i)
-	if v.Kind() != reflect.Ptr {
-		return errors.New("call of Decode passes non-pointer as second argument")
-	}
-
-	v = v.Elem()
-	if v.Kind() != reflect.Struct {
-		return errors.New("call of Decode passes pointer to non-struct as second argument")
-	}
+// RegisterDecoder registers a decoder of the values of type t, e.g. a third-party type not implementing
+// [encoding.TextUnmarshaler]. The decode function must return a value of type t. The registered decoder
+// takes priority over [encoding.TextUnmarshaler] implementation of the type.
+func RegisterDecoder(t reflect.Type, decode func(value string) (any, error)) Opt { //nolint:ireturn
+	return RegisterDecoderContext(t, func(_ context.Context, value string) (any, error) {
+		return decode(value)
+	})
+}
 
-	// query values lookup by its original and lowercased name
-	const doubleSize = 2
-	query := make(map[string][]string, doubleSize*len(r.URL.Query()))
+// RegisterDecoderContext registers a decoder of the values of type t, same as [request.RegisterDecoder].
+// The decode function receives the context of the decoding, e.g. to cancel an expensive lookup
+// or to read request-scoped values. See [request.Decoder.DecodeContext].
+func RegisterDecoderContext(t reflect.Type, decode func(context.Context, string) (any, error)) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		if d.decoders == nil {
+			d.decoders = make(map[reflect.Type]func(ctx context.Context, value string) (any, error))
+		}
 
-	for qk, qv := range r.URL.Query() {
-		lower := strings.ToLower(qk)
+		d.decoders[t] = decode
+	})
+}
 
-		if existing, ok := query[lower]; ok {
-			qv = append(qv, existing...)
+// BodyDecoder registers a decoder of a custom body format, e.g. "protobuf". The decode function
+// receives the request body and a pointer to the body field. The decoder is used for the body fields
+// having the format in the field tag, e.g. `body:"protobuf"`, or when no format is specified in the field tag
+// and the "Content-Type" request header matches one of the media types.
+// Registering "json" or "xml" format overrides the built-in decoding.
+func BodyDecoder(format string, decode func(r io.Reader, v any) error, mediaTypes ...string) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		if d.bodyDecoders == nil {
+			d.bodyDecoders = make(map[string]bodyDecoder)
 		}
 
-		query[qk] = qv
-		query[lower] = qv
-	}
-
-	for _, field := range flattenFields(v) {
-		tagValue, ok := field.Type.Tag.Lookup("body")
-		if ok {
-			err := decodeBody(r, tagValue, field.Value.Addr().Interface())
-			if err != nil {
-				return err
-			}
+		d.bodyDecoders[format] = bodyDecoder{decode: decode, mediaTypes: slices.Clone(mediaTypes)}
+	})
+}
 
-			continue
-		}
+// YAMLUnmarshaler enables decoding of YAML request body using the unmarshal function,
+// e.g. [gopkg.in/yaml.v3.Unmarshal]. The YAML body is decoded for the body fields having "yaml"
+// in the field tag, e.g. `body:"yaml"`, or when no format is specified in the field tag
+// and the "Content-Type" request header is "application/yaml" or "application/x-yaml".
+//
+// [gopkg.in/yaml.v3.Unmarshal]: https://pkg.go.dev/gopkg.in/yaml.v3#Unmarshal
+func YAMLUnmarshaler(unmarshal func(data []byte, v any) error) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.yamlUnmarshal = unmarshal
+	})
+}
 
-		_, ok = field.Type.Tag.Lookup("header")
-		if ok {
-			err := decodeHeaders()
-			if err != nil {
-				return err
-			}
+// XMLCharsetReader sets the function converting the XML body in the charset declared in the XML declaration
+// to UTF-8, e.g. [golang.org/x/net/html/charset.NewReaderLabel]. Without it, decoding of non-UTF-8 XML body fails.
+// See [encoding/xml.Decoder.CharsetReader].
+//
+// [golang.org/x/net/html/charset.NewReaderLabel]: https://pkg.go.dev/golang.org/x/net/html/charset#NewReaderLabel
+func XMLCharsetReader(charsetReader func(charset string, input io.Reader) (io.Reader, error)) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.xmlCharsetReader = charsetReader
+	})
+}
 
-			continue
-		}
+// XMLNonStrict makes decoding of XML body tolerate common mistakes of hand-written XML, e.g. unquoted
+// attribute values, unknown entities and unclosed HTML elements. See [encoding/xml.Decoder.Strict].
+func XMLNonStrict() Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.xmlNonStrict = true
+	})
+}
 
-		tagValue, ok = field.Type.Tag.Lookup("path")
-		if ok {
-			err := setValue(field.Value, []string{d.pathValue(r, tagValue)})
-			if err != nil {
-				return fmt.Errorf("path '%s': %w", tagValue, err)
-			}
-		}
+// XMLDefaultSpace sets the namespace of XML body elements not having a namespace, e.g. SOAP payloads
+// without namespace declarations. See [encoding/xml.Decoder.DefaultSpace].
+func XMLDefaultSpace(space string) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.xmlDefaultSpace = space
+	})
+}
 
-		// query params
-		err := decodeQuery(d.query, field.Value, field.Type, query)
-		if err != nil {
-			return err
+// BodyDiscriminator registers a discriminator of the JSON body decoded into the interface type t,
+// e.g. OpenAPI oneOf schemas selected by the "type" property. The discriminate function receives
+// the JSON body and returns a pointer to the new value of the concrete type. The body is decoded into
+// the value and the body field is set to the pointer:
+//
+//	request.BodyDiscriminator(reflect.TypeFor[Shape](), func(data json.RawMessage) (any, error) {
+//		var v struct {
+//			Type string `json:"type"`
+//		}
+//
+//		if err := json.Unmarshal(data, &v); err != nil {
+//			return nil, err
+//		}
+//
+//		switch v.Type {
+//		default:
+//			return nil, fmt.Errorf("unknown shape '%s'", v.Type)
+//		case "circle":
+//			return &Circle{}, nil
+//		case "square":
+//			return &Square{}, nil
+//		}
+//	})
+func BodyDiscriminator(t reflect.Type, discriminate func(data json.RawMessage) (any, error)) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		if d.discriminators == nil {
+			d.discriminators = make(map[reflect.Type]func(data json.RawMessage) (any, error))
 		}
-	}
 
-	return nil
+		d.discriminators[t] = discriminate
+	})
+}
+
+// BoolStrings extends the boolean literals accepted by [strconv.ParseBool], e.g.
+// BoolStrings([]string{"yes", "on"}, []string{"no", "off"}) decodes "?active=yes" as true.
+// The literals are matched case-insensitively. Other values fail to decode.
+func BoolStrings(trueValues, falseValues []string) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.boolTrue = append(d.boolTrue, trueValues...)
+		d.boolFalse = append(d.boolFalse, falseValues...)
+	})
+}
+
+// TimeLayouts overrides the default time layouts. Each value is parsed trying the layouts in order.
+// Besides Go time layouts, the named formats "date" (2006-01-02), "date-time" (RFC3339) and
+// "unix" (Unix time in seconds) are supported.
+func TimeLayouts(layouts ...string) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.timeLayouts = slices.Clone(layouts)
+	})
+}
+
+// OnDecodeDuration sets a callback invoked with the elapsed time after each decoding of a request,
+// regardless of the decoding result.
+func OnDecodeDuration(f func(d time.Duration)) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.onDecodeDuration = f
+	})
+}
+
+// CollectErrors makes the decoder continue decoding past per-field failures.
+// The decoding returns [request.Errors] containing all the field errors, e.g. [request.DecodeError]
+// with the parameter origin and name. The fields that failed to decode are left at zero value.
+func CollectErrors() Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.collectErrors = true
+	})
+}
+
+// AllowOrigins restricts decoding to the fields of the listed parameter origins:
+// "path", "query", "header", "cookie" and "body". Fields of other origins are ignored, e.g.
+// AllowOrigins("path", "query") prevents accidental decoding of the body.
+func AllowOrigins(origins ...string) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.origins = append(make([]string, 0, len(origins)), origins...)
+	})
+}
+
+// AllowContentTypes restricts the media types of the request body, e.g. "application/json".
+// If the target has a body field and the "Content-Type" request header is not one of
+// the media types, the decoding returns [request.ErrUnsupportedMediaType] without reading the body.
+func AllowContentTypes(mediaTypes ...string) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.contentTypes = make([]string, 0, len(mediaTypes))
+
+		for _, mediaType := range mediaTypes {
+			d.contentTypes = append(d.contentTypes, strings.ToLower(strings.TrimSpace(mediaType)))
+		}
+	})
+}
+
+// RejectMultiForScalar makes the decoding fail when a query param having multiple values
+// (e.g. "?id=1&id=2") is decoded into a single value field. By default, the first value is used.
+func RejectMultiForScalar() Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.rejectMultiForScalar = true
+	})
+}
+
+// Rules sets validation rules run after decoding. Each rule receives the presence of the fields
+// in the request by the field path - the names of the nested struct fields separated by a dot,
+// e.g. "Search" or "Filter.Status". A rule requiring at least one of the fields:
+//
+//	func(presence map[string]bool) error {
+//		if !presence["IDs"] && !presence["Search"] {
+//			return errors.New("either ids or search is required")
+//		}
+//
+//		return nil
+//	}
+//
+// All rule errors are returned when decoding with [request.CollectErrors] option.
+// The rules are not run when decoding into a map or a slice, the target has no fields.
+func Rules(rules ...func(presence map[string]bool) error) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.rules = append(d.rules, rules...)
+	})
+}
+
+// UseNumber makes the JSON body decoding unmarshal numbers into an interface{} as [encoding/json.Number]
+// instead of float64, e.g. to keep the precision of large IDs. See [encoding/json.Decoder.UseNumber].
+func UseNumber() Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.useNumber = true
+	})
+}
+
+// DisallowUnknownBodyFields makes the JSON body decoding fail when the body contains a field
+// not present in the target, e.g. a misspelled field. The error wraps [request.ErrUnknownField].
+// See [encoding/json.Decoder.DisallowUnknownFields].
+func DisallowUnknownBodyFields() Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.disallowUnknown = true
+	})
+}
+
+// DecompressBody makes the decoder decompress the request body by the "Content-Encoding"
+// request header: "gzip" or "deflate". The limit of [request.MaxBodyBytes] applies to the decompressed body.
+// Decoding of the body having other content encoding fails.
+func DecompressBody() Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.decompressBody = true
+	})
+}
+
+// OnUnknownQuery sets a callback invoked with sorted names of the query params not decoded into any field.
+// The callback is not invoked when all query params are known. Unlike rejecting the request,
+// it allows to log or measure unexpected query params.
+func OnUnknownQuery(f func(keys []string)) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.onUnknownQuery = f
+	})
+}
+
+// CaseSensitiveQuery makes the decoder match query param names exactly as declared in the field tag,
+// e.g. "?Value=1&value=2" are distinct params. The name of the field without a name in the field tag
+// is the field name, e.g. "Value" instead of "value". By default, the names are matched case-insensitively
+// and the values of differently cased names are merged.
+func CaseSensitiveQuery() Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.caseSensitiveQuery = true
+	})
+}
+
+// TrimSpace removes leading and trailing white space of the parameter values before decoding, including each value
+// of imploded lists, e.g. "?ids=1, 2, 3" is decoded as [1 2 3] and "?name= alex " as "alex".
+func TrimSpace() Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.trimSpace = true
+	})
+}
+
+// LenientArrays splits the single value of an exploded query param decoded into a slice by the delimiter
+// of the serialization style, e.g. "?ids=1,2,3" is decoded as [1 2 3] into `query:"ids"` of []int,
+// same as "?ids=1&ids=2&ids=3". It is the counterpart of reading the last value of an imploded param
+// received exploded. The values of a slice of strings must not contain the delimiter.
+func LenientArrays() Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.lenientArrays = true
+	})
+}
+
+// DisallowUnknownQuery makes the decoding fail with [request.UnknownParamError] when the request
+// has query params not decoded into any field, e.g. a misspelled filter name. The properties of deep objects
+// (e.g. "?filter[status]=open") are known if the deep object field is declared.
+func DisallowUnknownQuery() Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.disallowUnknownQuery = true
+	})
+}
+
+// MultipartMaxMemory overrides the maximum bytes of multipart/form-data body stored in memory.
+// The remainder of the files is stored on disk in temporary files. See [net/http.Request.ParseMultipartForm].
+func MultipartMaxMemory(maxMemory int64) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.multipartMaxMemory = maxMemory
+	})
+}
+
+// defaultMultipartMaxMemory is the same as the default in net/http.
+const defaultMultipartMaxMemory = 32 << 20
+
+// MaxBodyBytes limits the size of the request body. Decoding of the body exceeding the limit
+// returns [request.ErrBodyTooLarge]. By default, the body size is unlimited.
+func MaxBodyBytes(n int64) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.maxBodyBytes = n
+	})
+}
+
+// CSRFValidator sets the validator of CSRF tokens, e.g. comparing the token to the one stored in the session.
+// The token is read from the request header or the form field named in the "csrf" field tag:
+//
+//	var req struct {
+//		CSRFToken string `csrf:"X-CSRF-Token"`
+//	}
+//
+// Decoding returns [request.ErrInvalidCSRFToken] wrapping the validator error if the token is rejected.
+// Decoding the "csrf" field without the validator always returns [request.ErrInvalidCSRFToken].
+func CSRFValidator(validate func(r *http.Request, token string) error) Opt { //nolint:ireturn
+	return newOpt(func(d *Decoder) {
+		d.csrfValidator = validate
+	})
+}
+
+// NewDecoder returns a new decoder to decode [net/http.Request] data into Go struct.
+//
+// By default:
+//   - the decoder reads path value using
+//     https://pkg.go.dev/net/http#Request.PathValue. Override with [request.PathValue] option.
+//   - the decoder splits path value by comma for slice fields (simple style, e.g. "/users/3,4,5").
+//     Override with [request.PathSplitter] option.
+//   - the decoder uses [request.PathStyleSimple] path parameter style. Override with [request.PathStyle] option.
+//   - the decoder reads the combined field tag "oas". Override with [request.TagName] option.
+//   - the decoder uses exploded query parameters. Override with [request.QueryImplode]
+//     or [request.QueryExplode] option.
+//   - the decoder uses [request.QueryStyleForm] query parameter style. Override with [request.QueryStyle] option.
+//   - the decoder parses time using RFC3339 layout. Override with [request.TimeLayouts] option.
+//   - the decoder stores up to 32 MB of multipart/form-data body in memory.
+//     Override with [request.MultipartMaxMemory] option.
+//   - the decoder separates key-value list entries by "," and keys from values by ":".
+//     Override with [request.QueryKVListSeparators] option.
+func NewDecoder(opts ...Opt) Decoder {
+	decoder := Decoder{
+		pathValue:          func(r *http.Request, name string) string { return r.PathValue(name) },
+		pathSplitter:       func(raw string) []string { return strings.Split(raw, ",") },
+		pathStyle:          PathStyleSimple,
+		tagName:            defaultTagName,
+		plans:              new(sync.Map),
+		timeLayouts:        []string{time.RFC3339},
+		multipartMaxMemory: defaultMultipartMaxMemory,
+		query: queryConf{
+			exploded:   true,
+			style:      QueryStyleForm,
+			kvEntrySep: ",",
+			kvPairSep:  ":",
+		},
+	}
+
+	for _, opt := range opts {
+		opt.apply(&decoder)
+	}
+
+	return decoder
+}
+
+var defaultDecoder = NewDecoder()
+
+// Decode decodes an HTTP request into a Go struct according to OpenAPI 3 specification.
+func Decode(r *http.Request, i interface{}) error {
+	return defaultDecoder.Decode(r, i)
+}
+
+// DecodeContext decodes an HTTP request into a Go struct using the context.
+// See [request.Decoder.DecodeContext].
+func DecodeContext(ctx context.Context, r *http.Request, i interface{}) error {
+	return defaultDecoder.DecodeContext(ctx, r, i)
+}
+
+// DecodeTo decodes an HTTP request into a new value of type T and returns it:
+//
+//	req, err := request.DecodeTo[CreateUserRequest](r)
+//
+// See [request.Decode].
+func DecodeTo[T any](r *http.Request) (T, error) {
+	return DecodeWith[T](defaultDecoder, r)
+}
+
+// DecodeWith decodes an HTTP request into a new value of type T using the decoder and returns it.
+// Go methods cannot have type parameters, DecodeWith is the generic counterpart of [request.Decoder.Decode]:
+//
+//	decoder := request.NewDecoder(request.CollectErrors())
+//	req, err := request.DecodeWith[CreateUserRequest](decoder, r)
+//
+// The value is allocated if T is a pointer, e.g. DecodeWith[*CreateUserRequest]. On error,
+// the zero value of T is returned.
+func DecodeWith[T any](d Decoder, r *http.Request) (T, error) {
+	var v T
+
+	target := any(&v)
+
+	if rv := reflect.ValueOf(&v).Elem(); rv.Kind() == reflect.Ptr {
+		rv.Set(reflect.New(rv.Type().Elem()))
+		target = rv.Interface()
+	}
+
+	if err := d.Decode(r, target); err != nil {
+		var zero T
+
+		return zero, err
+	}
+
+	return v, nil
+}
+
+// Decode decodes an HTTP request into Go struct.
+//
+// The target may also be a map or a slice for simple endpoints. A map receives all query params,
+// e.g. map[string]string or map[string][]int. A slice receives the values of the only query param,
+// e.g. []int for "?id=1&id=2".
+//
+// The parameters are specified by the field tag of the origin ("query", "path", "header", "cookie" or "body"),
+// or by the combined field tag "oas" having the parameter name, origin and settings (see [request.TagName]):
+//
+//	var req struct {
+//		ID   int      `path:"id"`
+//		Tags []string `oas:"tags,query,form"` // same as `query:"tags,form"`
+//	}
+//
+// Decoding of query params conforms to the [Query Serialization] spec.
+//
+//	// required - decoding returns error if query param is not present
+//	var req struct {
+//		Name string `query:",required"`
+//	}
+//
+//	// default - ?id=1&id=2&id=3
+//	var req struct {
+//		Id []int // case insensitive match of field name and query parameter, see [request.CaseSensitiveQuery]
+//	}
+//
+//	// comma delimited - ?id=1,2,3
+//	var req struct {
+//		Id []int  `query:",form"`      // implicitly imploded
+//		Ids []int `query:"id,imploded` // form by default
+//	}
+//
+//	// pipe delimited - ?id=1|2|3
+//	var req struct {
+//		Id []int `query:",pipe" // implicitly imploded
+//	}
+//
+//	// space delimited - ?id=1%202%203
+//	var req struct {
+//		Id []int `query:",space"` // implicitly imploded
+//	}
+//
+//	// set different name - ?id=1,2,3
+//	var req struct {
+//		FilterClientIds []int `query:"id,form"` // implicitly imploded
+//	}
+//
+//	// comma or pipe delimited - ?id=1,2,3 or ?id=1|2|3
+//	var req struct {
+//		Id []int `query:",form,pipeDelimited"` // implicitly imploded
+//	}
+//
+//	// semicolon delimited - ?id=1;2;3
+//	var req struct {
+//		Id []int `query:",semicolonDelimited"` // implicitly imploded
+//	}
+//
+//	// literal dotted name, not a nested object - ?filter.status=open
+//	var req struct {
+//		Status string `query:"filter.status"`
+//	}
+//
+//	// properties are matched by the query field tag, json field tag or lowercased field name
+//	// - ?filter[user_id]=7&filter[status]=open
+//	var req struct {
+//		Filter struct {
+//			UserID int `json:"user_id"`
+//			Status string
+//		} `query:"filter,deepObject"`
+//	}
+//
+//	// nested deep object - ?filter[user][role]=admin&filter[ids][]=1&filter[ids][]=2
+//	var req struct {
+//		Filter struct {
+//			User struct {
+//				Role string
+//			}
+//			IDs []int `query:"ids"`
+//		} `query:"filter,deepObject"`
+//	}
+//
+//	// deep object into a map - ?filter[status]=open&filter[tag]=go
+//	var req struct {
+//		Filter map[string]string `query:"filter,deepObject"` // implicitly deep object without other style
+//	}
+//
+//	// named struct without style - ?user[name]=alex&user[age]=30, or ?name=alex&age=30
+//	// if none of the bracketed properties is present
+//	var req struct {
+//		User struct {
+//			Name string
+//			Age  int
+//		} `query:"user"`
+//	}
+//
+//	// imploded object, properties and values alternate - ?color=R,100,G,200,B,150
+//	var req struct {
+//		Color struct {
+//			R, G, B int
+//		} `query:"color,form"` // implicitly imploded, a struct or a map
+//	}
+//
+//	// positional values - ?bbox=-10.5,20,10.5,40
+//	var req struct {
+//		BBox struct {
+//			MinLon, MinLat, MaxLon, MaxLat float64
+//		} `query:"bbox,positional"` // implicitly imploded
+//	}
+//
+//	// base64 encoded JSON - ?cursor=eyJpZCI6N30
+//	var req struct {
+//		Cursor struct {
+//			ID int `json:"id"`
+//		} `query:",base64json"`
+//	}
+//
+//	// URL-safe base64 encoded value, with or without padding - ?token=AQID
+//	var req struct {
+//		Token []byte `query:",base64"`
+//	}
+//
+//	// presence flag - ?verbose and ?verbose= are true, ?verbose=false is false
+//	var req struct {
+//		Verbose bool `query:",flag"`
+//	}
+//
+//	// sorted values - ?id=3,1,2 is decoded as [1 2 3]
+//	var req struct {
+//		Id []int `query:",form,sorted"`
+//	}
+//
+//	// allowed values - ?status=open, unknown values are replaced with "unknown"
+//	var req struct {
+//		Status string `query:",enum=open|closed|unknown,enumFallback=unknown"`
+//	}
+//
+//	// explicit null - ?bio=null sets nil
+//	var req struct {
+//		Bio *string `query:",nullToken=null"`
+//	}
+//
+//	// key-value list - ?labels=env:prod,team:core
+//	var req struct {
+//		Labels map[string]string `query:",kvlist"`
+//	}
+//
+//	// key-value list with the separators of the field - ?labels=env=prod!team=core
+//	var req struct {
+//		Labels map[string]string `query:",entrySep=!,pairSep=="`
+//	}
+//
+//	// maximum length of each value - ?tags=a,b
+//	var req struct {
+//		Tags []string `query:",form,maxLen=32"`
+//	}
+//
+//	// default values when not present - ?limit=50
+//	var req struct {
+//		Limit int      `query:",default=20"`
+//		Sort  []string `query:",form,default=name|-created"`
+//	}
+//
+//	// numeric bounds - ?limit=50
+//	var req struct {
+//		Limit int `query:",min=1,max=100"`
+//	}
+//
+//	// raw value alongside the parsed values - ?ids=1,2,3
+//	var req struct {
+//		IDs    []int  `query:"ids,form"`
+//		RawIDs string `query:"ids,raw"` // "1,2,3"
+//	}
+//
+//	// value not percent-decoded - ?path=/docs/a%2Fb
+//	var req struct {
+//		Path string `query:"path,allowReserved"` // "/docs/a%2Fb"
+//	}
+//
+//	// fixed number of values, the missing values are zero - ?point=1,2,3
+//	var req struct {
+//		Point [3]float64 `query:",form"`
+//	}
+//
+//	// integers in other base - ?mask=ff&id=0x1f
+//	var req struct {
+//		Mask uint16 `query:",base=16"`
+//		ID   int    `query:",base=0"` // base by the prefix "0x", "0o" or "0b", decimal otherwise
+//	}
+//
+//	// values matching a regular expression, the pattern must be the last setting - ?slug=go-request
+//	var req struct {
+//		Slug string `query:",pattern=^[a-z0-9-]+$"`
+//	}
+//
+// Pointer fields distinguish absent and empty query params. The pointer stays nil if the param is absent,
+// e.g. "?" for *string and *[]int. An empty value is decoded as an empty string or a single empty value
+// of the slice of elements decoded from strings (strings, text unmarshalers and registered types),
+// e.g. "?name=" for *string is "" and "?tags=" for *[]string is [""]. An empty imploded value of other
+// slices is an empty slice, e.g. "?ids=" for *[]int with the field tag `query:"ids,form"` is [].
+//
+// The allowReserved setting reads the values from the raw query string, reserved characters
+// (":/?#[]@!$&'()*+,;=") are kept as sent by the client and "+" is not decoded as space. Imploded values
+// are split before percent-decoding, so the encoded delimiter is part of the value, e.g. "?v=a%2Cb,c"
+// is decoded as "a%2Cb" and "c" with the field tag `query:"v,form,allowReserved"`. The setting is
+// ignored for deep object properties.
+//
+// When several serialization styles are specified for a field, the imploded value is split
+// by any of the style delimiters. Values must not contain any of the delimiters, e.g. "?id=1,2|3"
+// is decoded as three values with the field tag `query:",form,pipeDelimited"`.
+//
+// The delimiter setting overrides the delimiter of the style and implicitly implodes the values,
+// e.g. "?ids=1::2::3" is decoded as three values with the field tag `query:"ids,delimiter=::"`.
+// The delimiter must not be empty and must not contain commas.
+//
+// Values of [time.Time] are decoded using RFC3339 layout by default. Override it with a named format
+// ("date", "date-time" or "unix") or a Go time layout. Several layouts separated by "|" are tried in order:
+//
+//	var req struct {
+//		From  time.Time   `query:"from,format=date"`            // ?from=2024-01-31
+//		To    time.Time   `query:"to,format=date-time"`         // ?to=2024-01-31T12:00:00Z
+//		At    time.Time   `query:"at,format=15:04"`             // ?at=12:00
+//		Dates []time.Time `query:"dates,form,format=date|unix"` // ?dates=2024-01-31,1700000000
+//		Day   time.Time   `path:"day,format=date"`              // /reports/2024-01-31
+//	}
+//
+// Use [encoding.TextUnmarshaler] to implement custom decoding.
+//
+// A path param with the required setting, e.g. `path:"id,required"`, returns [request.RequiredError]
+// if the path value getter (see [request.PathValue]) returns an empty string.
+//
+// Decoding of request headers matches canonical header names (see [net/http.CanonicalHeaderKey]):
+//
+//	// X-Request-Id: 8a2f
+//	var req struct {
+//		RequestID string `header:"x-request-id,required"`
+//	}
+//
+// Header values are split by comma for slice fields. Values of repeated headers are merged:
+//
+//	// Accept-Encoding: gzip, deflate
+//	// Accept-Encoding: br
+//	var req struct {
+//		AcceptEncoding []string `header:"Accept-Encoding"` // [gzip deflate br]
+//	}
+//
+// Headers having a prefix are collected into a map. The keys are canonical header names without the prefix:
+//
+//	// X-Meta-Foo: 1
+//	// X-Meta-Bar: 2
+//	var req struct {
+//		Meta map[string]string `header:"X-Meta-,prefix"` // {"Foo": "1", "Bar": "2"}
+//	}
+//
+// Decoding of cookies uses the cookie value:
+//
+//	// Cookie: session=8a2f
+//	var req struct {
+//		Session string `cookie:"session,required"`
+//	}
+//
+// Decoding of request body is simple - it uses either json or xml unmarshaller:
+//
+//	type Entity struct {
+//		Id int
+//	}
+//
+//	// If no field tag value specified, "Content-Type" request header is used to determine decoding.
+//	// Uses json if the header is absent. Returns [request.UnsupportedMediaTypeError] if the media type
+//	// is not known, e.g. "text/html".
+//	var req struct {
+//		Entity `body:""`
+//	}
+//
+//	// Always use JSON umarshalling, ignore "Content-Type" request header:
+//	var req struct {
+//		Entity `body:"json"`
+//	}
+//
+//	// Decode the nested JSON object, e.g. {"data":{"attributes":{"id":1}}}, the format is required:
+//	var req struct {
+//		Entity `oas:"data.attributes,body,json"`
+//	}
+//
+//	// Always use XML unmarshalling, ignore "Content-Type" request header:
+//	var req struct {
+//		Entity `body:"xml"`
+//	}
+//
+//	// Empty body leaves the zero value, decoding of required body returns error if the body is empty:
+//	var req struct {
+//		Entity `body:"json,required"`
+//	}
+//
+//	// Always use YAML unmarshalling, requires [request.YAMLUnmarshaler] option:
+//	var req struct {
+//		Entity `body:"yaml"`
+//	}
+//
+//	// Read the whole body into a string, []byte or [encoding.TextUnmarshaler], e.g. a signed webhook payload:
+//	var req struct {
+//		Payload []byte `body:"text"`
+//	}
+//
+//	// Decode CSV rows into a slice of structs, the columns are mapped by the header,
+//	// "text/csv" Content-Type is decoded as CSV by default:
+//	var req struct {
+//		Records []Record `oas:",body,csv,delimiter=;"`
+//	}
+//
+// If the target implements [request.Validator], Validate is called after decoding
+// and its error is returned as [request.ValidationError].
+//
+// Decoding of multipart/form-data body binds form values and files by the "form" field tag or
+// case-insensitive field name:
+//
+//	var req struct {
+//		Form struct {
+//			Title  string
+//			Avatar *multipart.FileHeader   `form:"avatar"`
+//			Photos []*multipart.FileHeader `form:"photos"`
+//		} `body:"multipart"`
+//	}
+//
+// [Query Serialization]: https://swagger.io/docs/specification/serialization/#query
+func (d Decoder) Decode(r *http.Request, i interface{}) error {
+	return d.DecodeContext(r.Context(), r, i)
+}
+
+// DecodeContext decodes an HTTP request into Go struct, same as [request.Decoder.Decode]. The context
+// is passed to the decoders registered with [request.RegisterDecoderContext], [request.Decoder.Decode] passes
+// the request context. Decoding fails if the context is done.
+func (d Decoder) DecodeContext(ctx context.Context, r *http.Request, i interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+
+	d.ctx = ctx
+
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr {
+		return errors.New("call of Decode passes non-pointer as second argument")
+	}
+
+	v = v.Elem()
+
+	var err error
+
+	switch v.Kind() { //nolint:exhaustive
+	default:
+		return fmt.Errorf("call of Decode passes pointer to %s as second argument, want struct, map or slice", v.Type())
+	case reflect.Map, reflect.Slice:
+		err = d.decodeQueryInto(r, v)
+	case reflect.Struct:
+		err = d.decode(r, d.fields(v), nil)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return validate(i)
+}
+
+// validate calls [request.Validator.Validate] of the targets implementing [request.Validator].
+func validate(targets ...any) error {
+	for _, target := range targets {
+		if v, ok := target.(Validator); ok {
+			if err := v.Validate(); err != nil {
+				return ValidationError{Err: err}
+			}
+		}
+	}
+
+	return nil
+}
+
+// DecodeWithReport decodes an HTTP request into a Go struct and returns the paths of the fields present
+// in the request, e.g. to apply a partial update (PATCH) not touching the absent fields.
+// See [request.Decoder.DecodeWithReport].
+func DecodeWithReport(r *http.Request, i interface{}) ([]string, error) {
+	return defaultDecoder.DecodeWithReport(r, i)
+}
+
+// DecodeWithReport decodes an HTTP request into a Go struct, same as [request.Decoder.Decode], and returns
+// the paths of the fields present in the request in the order of the fields. The path of a field of
+// a nested struct has the names of the parent fields separated by a dot, e.g. "Filter.Status".
+// The names of embedded structs are omitted. Fields having default values are not present.
+func (d Decoder) DecodeWithReport(r *http.Request, i interface{}) ([]string, error) {
+	d.ctx = r.Context()
+
+	v := reflect.ValueOf(i)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("call of DecodeWithReport passes non-struct pointer as second argument")
+	}
+
+	var present []string
+
+	if err := d.decode(r, d.fields(v.Elem()), &present); err != nil {
+		return nil, err
+	}
+
+	if err := validate(i); err != nil {
+		return nil, err
+	}
+
+	return present, nil
+}
+
+// decodeQueryInto decodes query params into the map or the slice. The map receives all query params,
+// the slice receives the values of the only query param, e.g. "?id=1&id=2".
+func (d Decoder) decodeQueryInto(r *http.Request, v reflect.Value) error {
+	if d.onDecodeDuration != nil {
+		start := time.Now()
+
+		defer func() { d.onDecodeDuration(time.Since(start)) }()
+	}
+
+	if v.Kind() == reflect.Map {
+		if err := d.setMapValue(v, r.URL.Query(), fieldConf{}); err != nil {
+			return fmt.Errorf("query: %w", err)
+		}
+
+		return nil
+	}
+
+	query := parseQuery(r.URL.RawQuery, d.caseSensitiveQuery)
+
+	if len(query.names) > 1 {
+		return fmt.Errorf("query: want single param for %s, got %d", v.Type(), len(query.names))
+	}
+
+	for _, name := range query.names {
+		conf := fieldConf{name: name, style: d.query.style, exploded: d.query.exploded}
+		values, _ := parseQueryValues(conf, query)
+
+		if err := d.setValue(v, values, conf); err != nil {
+			return DecodeError{Origin: originQuery, Name: name, Err: err}
+		}
+	}
+
+	return nil
+}
+
+// DecodeMulti decodes an HTTP request into several Go structs in a single pass. It is useful to separate
+// query params and request body into distinct types. The request body is read once,
+// even if it is decoded into several targets.
+func DecodeMulti(r *http.Request, targets ...any) error {
+	return defaultDecoder.DecodeMulti(r, targets...)
+}
+
+// DecodeMulti decodes an HTTP request into several Go structs in a single pass.
+// See [request.Decoder.Decode] for the decoding rules.
+func (d Decoder) DecodeMulti(r *http.Request, targets ...any) error {
+	d.ctx = r.Context()
+
+	var fields []field
+
+	for i, target := range targets {
+		v := reflect.ValueOf(target)
+		if v.Kind() != reflect.Ptr {
+			return fmt.Errorf("call of DecodeMulti passes non-pointer as target %d", i)
+		}
+
+		v = v.Elem()
+		if v.Kind() != reflect.Struct {
+			return fmt.Errorf("call of DecodeMulti passes pointer to non-struct as target %d", i)
+		}
+
+		fields = append(fields, d.fields(v)...)
+	}
+
+	if err := d.decode(r, fields, nil); err != nil {
+		return err
+	}
+
+	return validate(targets...)
+}
+
+// DecodeGeneric decodes an HTTP request into a generic structure without a target type.
+// See [request.Decoder.DecodeGeneric] for the shape of the result.
+func DecodeGeneric(r *http.Request) (map[string]any, error) {
+	return defaultDecoder.DecodeGeneric(r)
+}
+
+// DecodeStream decodes the elements of a top-level array in the request body one by one.
+// See [request.Decoder.DecodeStream].
+func DecodeStream(r *http.Request, format string, fn func(elem json.RawMessage) error) error {
+	return defaultDecoder.DecodeStream(r, format, fn)
+}
+
+// DecodeStream decodes the elements of a top-level array in the request body one by one
+// and calls fn for each element, e.g. to ingest large uploads without holding the whole array in memory.
+// Only "json" format is supported. Decoding stops at the first error returned by fn.
+func (d Decoder) DecodeStream(r *http.Request, format string, fn func(elem json.RawMessage) error) error {
+	if format != "json" {
+		return fmt.Errorf(`want "json", got unsupported "%s"`, format)
+	}
+
+	body := io.Reader(r.Body)
+	if d.maxBodyBytes > 0 {
+		body = http.MaxBytesReader(nil, r.Body, d.maxBodyBytes)
+	}
+
+	dec := json.NewDecoder(body)
+
+	token, err := dec.Token()
+	if err != nil {
+		return bodyError(fmt.Errorf("decode JSON body: %w", err))
+	}
+
+	if token != json.Delim('[') {
+		return fmt.Errorf("decode JSON body: want array, got %v", token)
+	}
+
+	for i := 0; dec.More(); i++ {
+		var elem json.RawMessage
+		if err := dec.Decode(&elem); err != nil {
+			return bodyError(fmt.Errorf("decode JSON body: element %d: %w", i, err))
+		}
+
+		if err := fn(elem); err != nil {
+			return err
+		}
+	}
+
+	// closing bracket
+	if _, err := dec.Token(); err != nil {
+		return bodyError(fmt.Errorf("decode JSON body: %w", err))
+	}
+
+	return nil
+}
+
+// pathWildcard matches wildcards of [net/http.ServeMux] patterns, e.g. "{id}" or "{path...}".
+var pathWildcard = regexp.MustCompile(`\{([^}.$]+)(?:\.\.\.)?\}`)
+
+// DecodeGeneric decodes an HTTP request into a generic structure, e.g. for logging or schemaless gateways.
+// The result contains the following keys:
+//   - "query" - map[string][]string, all query params;
+//   - "path" - map[string]string, path values by wildcard names of the matched pattern
+//     (see [net/http.Request.Pattern]), present only if the pattern is known;
+//   - "header" - map[string][]string, all request headers;
+//   - "body" - any, JSON body decoded into Go values (see [encoding/json.Unmarshal])
+//     if Content-Type is JSON, otherwise the body as a string. Present only if the body is not empty.
+//
+// The body is decompressed and limited in size same as decoding into a struct,
+// see [request.DecompressBody] and [request.MaxBodyBytes] options.
+func (d Decoder) DecodeGeneric(r *http.Request) (map[string]any, error) {
+	generic := map[string]any{
+		"query":  map[string][]string(r.URL.Query()),
+		"header": map[string][]string(r.Header.Clone()),
+	}
+
+	if r.Pattern != "" {
+		path := make(map[string]string)
+
+		for _, match := range pathWildcard.FindAllStringSubmatch(r.Pattern, -1) {
+			path[match[1]] = d.pathValue(r, match[1])
+		}
+
+		generic["path"] = path
+	}
+
+	if r.Body == nil {
+		return generic, nil
+	}
+
+	closeBody, err := d.wrapBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	defer closeBody()
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, bodyError(fmt.Errorf("read body: %w", err))
+	}
+
+	if len(body) == 0 {
+		return generic, nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" && !strings.HasSuffix(mediaType, "+json") {
+		generic["body"] = string(body)
+		return generic, nil
+	}
+
+	var v any
+
+	if err := json.Unmarshal(body, &v); err != nil {
+		return nil, fmt.Errorf("decode JSON body: %w", err)
+	}
+
+	generic["body"] = v
+
+	return generic, nil
+}
+
+// wrapBody replaces the request body with the decompressed body (see [request.DecompressBody])
+// limited in size (see [request.MaxBodyBytes]). The returned function closes the decompressing reader.
+func (d Decoder) wrapBody(r *http.Request) (func(), error) {
+	closeBody := func() {}
+
+	if d.decompressBody {
+		body, err := decompress(r)
+		if err != nil {
+			return nil, DecodeError{Origin: originBody, Err: fmt.Errorf("decompress body: %w", err)}
+		}
+
+		if body != nil {
+			closeBody = func() { body.Close() }
+			r.Body = body
+		}
+	}
+
+	if d.maxBodyBytes > 0 {
+		r.Body = http.MaxBytesReader(nil, r.Body, d.maxBodyBytes)
+	}
+
+	return closeBody, nil
+}
+
+// decodeState contains the data of a single request decoding.
+type decodeState struct {
+	r *http.Request
+	// query values looked up by the case-insensitive name
+	query queryValues
+	// body is the buffered request body when the body is decoded into several fields
+	body []byte
+	// buffered reports whether the request body is buffered
+	buffered bool
+}
+
+// bodyReader returns the reader of the request body.
+func (s *decodeState) bodyReader() io.Reader {
+	if s.buffered {
+		return bytes.NewReader(s.body)
+	}
+
+	return s.r.Body
+}
+
+// decode decodes the fields and appends the paths of the present fields to the report if not nil.
+func (d Decoder) decode(r *http.Request, fields []field, report *[]string) error {
+	if d.onDecodeDuration != nil {
+		start := time.Now()
+
+		defer func() { d.onDecodeDuration(time.Since(start)) }()
+	}
+
+	query := parseQuery(r.URL.RawQuery, d.caseSensitiveQuery)
+
+	var (
+		errs       Errors
+		metaFields []field
+		bodyFields int
+		// presence of fields in the request by field path, used by the rules only
+		presence map[string]bool
+	)
+
+	if len(d.rules) > 0 {
+		presence = make(map[string]bool, len(fields))
+	}
+
+	for _, field := range fields {
+		if field.Origin == originBody && d.allowsOrigin(originBody) {
+			bodyFields++
+		}
+	}
+
+	if d.contentTypes != nil && bodyFields > 0 {
+		if err := d.checkContentType(r); err != nil {
+			return err
+		}
+	}
+
+	if bodyFields > 0 && r.Body != nil {
+		closeBody, err := d.wrapBody(r)
+		if err != nil {
+			return err
+		}
+
+		defer closeBody()
+	}
+
+	state := &decodeState{r: r, query: query}
+
+	// read the body once if it is decoded into several fields
+	if bodyFields > 1 {
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return bodyError(fmt.Errorf("read body: %w", err))
+		}
+
+		state.body = body
+		state.buffered = true
+	}
+
+	var decodeFields func(fields []field) (bool, error)
+
+	// decodeFields decodes the fields, records the presence of the fields and collects the errors.
+	// It reports whether any of the fields is present in the request.
+	decodeFields = func(fields []field) (bool, error) {
+		var anyPresent bool
+
+		for _, field := range fields {
+			if field.Origin == originMeta {
+				metaFields = append(metaFields, field)
+				continue
+			}
+
+			var (
+				present bool
+				err     error
+			)
+
+			if nested, target, ok := d.bracketFields(state, field); ok {
+				// the fields of the named struct are reported instead of the struct, same as flattened fields
+				if present, err = decodeFields(nested); err == nil {
+					switch {
+					case present && target.IsValid():
+						field.Value.Set(target)
+					case !present && field.Conf.required:
+						err = RequiredError{Origin: originQuery, Name: field.Conf.name}
+					}
+				}
+			} else {
+				present, err = d.decodeField(state, field)
+
+				if report != nil && present {
+					*report = append(*report, field.Path)
+				}
+			}
+
+			if err != nil {
+				if !d.collectErrors {
+					return anyPresent, err
+				}
+
+				// do not leave partially decoded values, e.g. a slice with some of the values
+				field.Value.SetZero()
+
+				errs = append(errs, err)
+			}
+
+			if presence != nil {
+				presence[field.Path] = present
+			}
+
+			anyPresent = anyPresent || present
+		}
+
+		return anyPresent, nil
+	}
+
+	if _, err := decodeFields(fields); err != nil {
+		return err
+	}
+
+	if d.onUnknownQuery != nil || d.disallowUnknownQuery {
+		if unknown := d.unknownQuery(query, fields); len(unknown) > 0 {
+			if d.onUnknownQuery != nil {
+				d.onUnknownQuery(unknown)
+			}
+
+			if d.disallowUnknownQuery {
+				if !d.collectErrors {
+					return UnknownParamError{Names: unknown}
+				}
+
+				errs = append(errs, UnknownParamError{Names: unknown})
+			}
+		}
+	}
+
+	for _, rule := range d.rules {
+		if err := rule(presence); err != nil {
+			if !d.collectErrors {
+				return err
+			}
+
+			errs = append(errs, err)
+		}
+	}
+
+	if len(metaFields) > 0 {
+		if err := setMeta(r, metaFields, len(errs)); err != nil {
+			return err
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// Meta contains the request metadata. The decoder fills a field of type Meta or *Meta having "meta" origin
+// in the combined field tag, or "meta" field tag:
+//
+//	var req struct {
+//		Meta request.Meta `oas:",meta"` // or `meta:""`
+//	}
+type Meta struct {
+	Method      string // HTTP method
+	Path        string // URL path
+	Pattern     string // matched [net/http.ServeMux] pattern
+	ContentType string // Content-Type request header
+	Errors      int    // number of field errors when decoding with [request.CollectErrors] option
+}
+
+var metaType = reflect.TypeFor[Meta]()
+
+func setMeta(r *http.Request, fields []field, errCount int) error {
+	meta := Meta{
+		Method:      r.Method,
+		Path:        r.URL.Path,
+		Pattern:     r.Pattern,
+		ContentType: r.Header.Get("Content-Type"),
+		Errors:      errCount,
+	}
+
+	for _, field := range fields {
+		switch field.Value.Type() {
+		default:
+			return fmt.Errorf("meta field %s: want request.Meta, got %s", field.Type.Name, field.Value.Type())
+		case metaType:
+			field.Value.Set(reflect.ValueOf(meta))
+		case reflect.PointerTo(metaType):
+			field.Value.Set(reflect.ValueOf(&meta))
+		}
+	}
+
+	return nil
+}
+
+// checkContentType checks whether the Content-Type request header is one of the allowed media types.
+func (d Decoder) checkContentType(r *http.Request) error {
+	contentType := r.Header.Get("Content-Type")
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !slices.Contains(d.contentTypes, mediaType) {
+		return UnsupportedMediaTypeError{MediaType: contentType}
+	}
+
+	return nil
+}
+
+// unknownQuery returns sorted names of query params not decoded into any of the fields.
+// The differently cased names of the same param are reported once.
+func (d Decoder) unknownQuery(query queryValues, fields []field) []string {
+	var names, prefixes []string
+
+	fields = slices.Clone(fields)
+
+	for i := 0; i < len(fields); i++ {
+		field := fields[i]
+		if field.Origin != originQuery || !d.allowsOrigin(originQuery) {
+			continue
+		}
+
+		conf := field.Conf
+		if field.Err != nil || conf.name == "-" {
+			continue
+		}
+
+		// both bracketed properties and the params of the struct fields are known
+		if conf.brackets {
+			t := field.Value.Type()
+			if t.Kind() == reflect.Ptr {
+				t = t.Elem()
+			}
+
+			fields = append(fields, d.fields(reflect.New(t).Elem())...)
+		}
+
+		if conf.style == QueryStyleDeepObject || conf.brackets {
+			prefixes = append(prefixes, conf.name+"[")
+		} else {
+			names = append(names, conf.name)
+		}
+	}
+
+	var unknown []string
+
+	for _, key := range query.names {
+		known := slices.ContainsFunc(names, func(name string) bool { return query.equal(name, key) }) ||
+			slices.ContainsFunc(prefixes, func(prefix string) bool {
+				return len(key) > len(prefix) && query.equal(key[:len(prefix)], prefix)
+			})
+
+		if !known {
+			unknown = append(unknown, key)
+		}
+	}
+
+	slices.Sort(unknown)
+
+	return unknown
+}
+
+// allowsOrigin reports whether the decoder decodes fields of the parameter origin.
+func (d Decoder) allowsOrigin(origin string) bool {
+	return d.origins == nil || slices.Contains(d.origins, origin)
+}
+
+// decompress returns the reader of the request body decompressed by the content encoding,
+// or nil if the body is not compressed.
+func decompress(r *http.Request) (io.ReadCloser, error) {
+	switch encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding"))); encoding {
+	default:
+		return nil, fmt.Errorf("unsupported content encoding '%s'", encoding)
+	case "", "identity":
+		return nil, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, fmt.Errorf("read gzip header: %w", err)
+		}
+
+		return zr, nil
+	case "deflate":
+		// "deflate" is zlib format, but some clients send raw deflate data
+		br := bufio.NewReader(r.Body)
+
+		if header, _ := br.Peek(2); len(header) == 2 && header[0]&0x0f == 8 && (int(header[0])<<8|int(header[1]))%31 == 0 {
+			zr, err := zlib.NewReader(br)
+			if err != nil {
+				return nil, fmt.Errorf("read zlib header: %w", err)
+			}
+
+			return zr, nil
+		}
+
+		return flate.NewReader(br), nil
+	}
+}
+
+// bodyError replaces the error of exceeding the body size limit with [request.ErrBodyTooLarge].
+func bodyError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return fmt.Errorf("%w: limit is %d bytes", ErrBodyTooLarge, maxBytesErr.Limit)
+	}
+
+	return err
+}
+
+// decodeField decodes the field and reports whether the field is present in the request.
+func (d Decoder) decodeField(state *decodeState, field field) (bool, error) {
+	r := state.r
+
+	if !d.allowsOrigin(field.Origin) {
+		return false, nil
+	}
+
+	if field.Err != nil {
+		return false, field.Err
+	}
+
+	switch field.Origin {
+	default: // query params
+		conf := field.Conf
+
+		// bracketed properties of the named struct, see bracketFields
+		if conf.brackets {
+			conf.style = QueryStyleDeepObject
+		}
+
+		return d.decodeQuery(field.Value, conf, state.query)
+	case originBody:
+		name := field.Conf.name
+
+		err := d.decodeBody(r, state.bodyReader(), field.Conf, field.Value.Addr().Interface())
+		if errors.Is(err, errEmptyBody) {
+			if field.Conf.required {
+				// the body format is not a parameter name, e.g. "json"
+				return false, RequiredError{Origin: originBody, Name: originBody}
+			}
+
+			return false, nil
+		}
+
+		if err != nil {
+			return false, DecodeError{Origin: originBody, Name: name, Err: bodyError(err)}
+		}
+
+		return true, nil
+	case originHeader:
+		return d.decodeHeader(r, field.Value, field.Conf)
+	case originCookie:
+		return d.decodeCookie(r, field.Value, field.Conf)
+	case originCSRF:
+		return d.decodeCSRF(r, field.Value, field.Conf)
+	case originPath:
+		return d.decodePath(r, field.Value, field.Conf)
+	}
+}
+
+// bracketFields returns the fields of the named struct decoded from the params of the struct fields,
+// e.g. "?name=alex" for `query:"user"`, if none of the bracketed properties is present, e.g. "?user[name]=alex".
+// The fields of the pointer to the struct are decoded into the returned target, the pointer is set to
+// the target if any of the fields is present. It reports false if the field is not the named struct or
+// it is decoded from the bracketed properties as deep object.
+func (d Decoder) bracketFields(state *decodeState, field field) ([]field, reflect.Value, bool) {
+	if !field.Conf.brackets || field.Err != nil || !d.allowsOrigin(field.Origin) {
+		return nil, reflect.Value{}, false
+	}
+
+	if qv := parseQueryValuesDeep(field.Conf.name, state.query); len(qv.names) > 0 {
+		return nil, reflect.Value{}, false
+	}
+
+	v, target := field.Value, reflect.Value{}
+
+	if v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			target = reflect.New(v.Type().Elem())
+			v = target
+		}
+
+		v = v.Elem()
+	}
+
+	fields := d.fields(v)
+
+	for i := range fields {
+		plan := *fields[i].fieldPlan
+		plan.Path = field.Path + "." + plan.Path
+		fields[i].fieldPlan = &plan
+	}
+
+	return fields, target, true
+}
+
+func (d Decoder) decodePath(r *http.Request, fv reflect.Value, conf fieldConf) (bool, error) {
+	value := d.pathValue(r, conf.name)
+
+	// the path value getter returns an empty string for a missing segment
+	if value == "" && conf.required {
+		return false, RequiredError{Origin: originPath, Name: conf.name}
+	}
+
+	values, err := d.parsePathValues(conf, value, isSlice(fv.Type()))
+	if err != nil {
+		return true, DecodeError{Origin: originPath, Name: conf.name, Err: err}
+	}
+
+	err = d.setValue(fv, values, conf)
+	if err == nil {
+		err = checkRange(conf, fv)
+	}
+
+	if err != nil {
+		return true, DecodeError{Origin: originPath, Name: conf.name, Err: err}
+	}
+
+	return len(values) > 1 || len(values) == 1 && values[0] != "", nil
+}
+
+// parsePathValues parses the path value serialized in the path style of the field.
+func (d Decoder) parsePathValues(conf fieldConf, raw string, multi bool) ([]string, error) {
+	switch conf.style {
+	default:
+		if multi {
+			return d.pathSplitter(raw), nil
+		}
+
+		return []string{raw}, nil
+	case PathStyleLabel:
+		if raw == "" {
+			return []string{""}, nil
+		}
+
+		value, ok := strings.CutPrefix(raw, ".")
+		if !ok {
+			return nil, fmt.Errorf(`want label style value prefixed with ".", got "%s"`, raw)
+		}
+
+		switch {
+		case !multi:
+			return []string{value}, nil
+		case conf.exploded:
+			return strings.Split(value, "."), nil
+		default:
+			return strings.Split(value, ","), nil
+		}
+	case PathStyleMatrix:
+		if raw == "" {
+			return []string{""}, nil
+		}
+
+		segments, ok := strings.CutPrefix(raw, ";")
+		if !ok {
+			return nil, fmt.Errorf(`want matrix style value prefixed with ";", got "%s"`, raw)
+		}
+
+		var values []string
+
+		for _, segment := range strings.Split(segments, ";") {
+			name, value, _ := strings.Cut(segment, "=")
+			if name != conf.name {
+				return nil, fmt.Errorf(`want matrix style parameter "%s", got "%s"`, conf.name, name)
+			}
+
+			values = append(values, value)
+		}
+
+		if multi && !conf.exploded && len(values) == 1 {
+			return strings.Split(values[0], ","), nil
+		}
+
+		return values, nil
+	}
+}
+
+// fieldOrigin returns the parameter origin of the field defined by the field tag. Defaults to query.
+func fieldOrigin(sf reflect.StructField) string {
+	for _, origin := range []string{originBody, originHeader, originCookie, originPath, originCSRF, originMeta} {
+		if _, ok := sf.Tag.Lookup(origin); ok {
+			return origin
+		}
+	}
+
+	return originQuery
+}
+
+// isSlice reports whether the type (or the type it points to) is a slice or an array, except a slice of bytes.
+func isSlice(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8 || t.Kind() == reflect.Array
+}
+
+// isStringSlice reports whether the type (or the type it points to) is a slice or an array of elements
+// decoded from any string including the empty one - strings, text unmarshalers and registered types.
+func (d Decoder) isStringSlice(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+		return false
+	}
+
+	elem := t.Elem()
+	for elem.Kind() == reflect.Ptr {
+		elem = elem.Elem()
+	}
+
+	if _, ok := d.decoders[elem]; ok {
+		return true
+	}
+
+	// time.Time is a text unmarshaler, but it is parsed by the time layouts
+	if elem != timeType && reflect.PointerTo(elem).Implements(textUnmarshalerType) {
+		return true
+	}
+
+	return elem.Kind() == reflect.String
+}
+
+// isMultiValue reports whether the type (or the type it points to) is decoded from multiple values.
+func (d Decoder) isMultiValue(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	_, flags := d.flags[t]
+
+	return flags || isSlice(t)
+}
+
+// fieldPlan is the decoding plan of a struct field. It is computed once per struct type.
+type fieldPlan struct {
+	Index  []int  // index sequence for [reflect.Value.FieldByIndex]
+	Path   string // names of the nested struct fields, e.g. "Filter.Status"
+	Type   reflect.StructField
+	Origin string    // parameter location, e.g. "query"
+	Conf   fieldConf // parsed field tag
+	Err    error     // field tag parsing error
 }
 
 type field struct {
 	Value reflect.Value
-	Type  reflect.StructField
+	*fieldPlan
+}
+
+// fields returns the fields of the struct value to decode.
+func (d Decoder) fields(v reflect.Value) []field {
+	plan := d.plan(v.Type())
+	fields := make([]field, len(plan))
+
+	for i := range plan {
+		fields[i] = field{Value: v.FieldByIndex(plan[i].Index), fieldPlan: &plan[i]}
+	}
+
+	return fields
+}
+
+// plan returns the decoding plan of the struct type. The plan is cached by the decoder,
+// it is safe for concurrent use.
+func (d Decoder) plan(t reflect.Type) []fieldPlan {
+	if d.plans != nil {
+		if plan, ok := d.plans.Load(t); ok {
+			return plan.([]fieldPlan) //nolint:forcetypeassert
+		}
+	}
+
+	plan := d.flattenFields(t, nil)
+
+	for i := range plan {
+		p := &plan[i]
+
+		var tag string
+
+		p.Path = fieldPath(t, p.Index)
+		p.Origin, tag = d.fieldTag(p.Type)
+		p.Conf, p.Err = d.parseFieldConf(p.Type, p.Origin, tag)
+	}
+
+	if d.plans != nil {
+		actual, _ := d.plans.LoadOrStore(t, plan)
+		plan = actual.([]fieldPlan) //nolint:forcetypeassert
+	}
+
+	return plan
+}
+
+// fieldPath returns the names of the nested struct fields separated by a dot, e.g. "Filter.Status".
+// The names of embedded structs are omitted.
+func fieldPath(t reflect.Type, index []int) string {
+	names := make([]string, 0, len(index))
+
+	for i, fi := range index {
+		sf := t.Field(fi)
+		t = sf.Type
+
+		if !sf.Anonymous || i == len(index)-1 {
+			names = append(names, sf.Name)
+		}
+	}
+
+	return strings.Join(names, ".")
+}
+
+// fieldTag returns the parameter origin and the field tag value of the origin, e.g. "query" and "id,form"
+// for both `query:"id,form"` and `oas:"id,query,form"` field tags. The combined field tag (see [request.TagName])
+// takes priority over the field tags of the origins. The empty origin of the combined field tag is "query",
+// e.g. `oas:"id,,required"`. Unknown origins are rejected when parsing the field configuration.
+func (d Decoder) fieldTag(sf reflect.StructField) (string, string) {
+	if tag, ok := sf.Tag.Lookup(d.tagName); ok && d.tagName != "" {
+		name, rest, _ := strings.Cut(tag, ",")
+		origin, settings, _ := strings.Cut(rest, ",")
+
+		if origin = strings.TrimSpace(origin); origin == "" {
+			origin = originQuery
+		}
+
+		if settings != "" {
+			name += "," + settings
+		}
+
+		return origin, name
+	}
+
+	origin := fieldOrigin(sf)
+
+	return origin, sf.Tag.Get(origin)
+}
+
+// parseFieldConf parses the field tag value of the origin and sets the default parameter name.
+func (d Decoder) parseFieldConf(ft reflect.StructField, origin, tag string) (fieldConf, error) {
+	var (
+		conf fieldConf
+		err  error
+	)
+
+	switch origin {
+	default:
+		// e.g. a typo `oas:"id,qeury"` or a setting in place of the origin `oas:"id,required"`
+		err = fmt.Errorf("unknown origin '%s', want one of %s", origin, strings.Join(origins, ", "))
+	case originBody:
+		conf, err = parseBodyTag(tag)
+	case originCSRF, originMeta:
+		conf.name = tag
+	case originPath:
+		// path values are imploded by default
+		conf, err = parseFieldTag(queryConf{style: d.pathStyle}, tag)
+	case originQuery, originHeader, originCookie:
+		conf, err = parseFieldTag(d.query, tag)
+	}
+
+	if err != nil {
+		return fieldConf{}, fmt.Errorf("parse field %s tag: %w", ft.Name, err)
+	}
+
+	if (conf.min != nil || conf.max != nil) && !isNumeric(ft.Type) {
+		return fieldConf{}, fmt.Errorf("parse field %s tag: want numeric type for min and max, got %s", ft.Name, ft.Type)
+	}
+
+	if conf.flag && !isBool(ft.Type) {
+		return fieldConf{}, fmt.Errorf("parse field %s tag: want bool for flag, got %s", ft.Name, ft.Type)
+	}
+
+	if conf.base64 && !isBase64Type(ft.Type) {
+		return fieldConf{}, fmt.Errorf("parse field %s tag: want string or []byte for base64, got %s", ft.Name, ft.Type)
+	}
+
+	if err := d.checkDefault(conf, ft.Type); err != nil {
+		return fieldConf{}, fmt.Errorf("parse field %s tag: invalid default: %w", ft.Name, err)
+	}
+
+	// map is a deep object unless other serialization is specified, e.g. "?filter[status]=open"
+	if origin == originQuery && len(conf.styles) == 0 && !conf.kvlist && !conf.base64json && !conf.raw {
+		t := ft.Type
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+
+		if t.Kind() == reflect.Map && d.isDeepObject(t) {
+			conf.style = QueryStyleDeepObject
+		}
+
+		// named struct or pointer to struct, e.g. `query:"user"`
+		isStruct := ft.Type.Kind() == reflect.Struct ||
+			ft.Type.Kind() == reflect.Ptr && ft.Type.Elem().Kind() == reflect.Struct
+		conf.brackets = isStruct && conf.style != QueryStyleDeepObject &&
+			!conf.positional && conf.name != "" && conf.name != "-" && d.isDeepObject(ft.Type)
+	}
+
+	if conf.name == "" {
+		switch origin {
+		case originQuery:
+			// use lowercased field name, or the field name if case-sensitive
+			conf.name = ft.Name
+			if !d.caseSensitiveQuery {
+				conf.name = strings.ToLower(ft.Name)
+			}
+		case originHeader, originCookie, originCSRF:
+			conf.name = ft.Name
+		}
+	}
+
+	return conf, nil
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// flattenFields flattens all fields of struct type, the following fields are not flattened:
+// - fields having "body" or "meta" field tag;
+// - fields having "query" field tag with "deepObject" serialization, "positional" or "base64json" values;
+// - fields having "query" field tag with imploded object serialization, e.g. `query:"color,form"`;
+// - fields having encoding.TextUnmarshaler interface;
+// - fields of types having a decoder registered with [request.RegisterDecoder].
+func (d Decoder) flattenFields(t reflect.Type, index []int) []fieldPlan {
+	fields := make([]fieldPlan, 0, t.NumField())
+
+	for i := range t.NumField() {
+		sft := t.Field(i)
+
+		// NOTE: ignore unexported fields in struct.
+		if !sft.IsExported() {
+			continue
+		}
+
+		sfi := append(slices.Clone(index), i)
+
+		if _, ok := d.decoders[sft.Type]; ok || reflect.PointerTo(sft.Type).Implements(textUnmarshalerType) {
+			fields = append(fields, fieldPlan{Index: sfi, Type: sft})
+			continue
+		}
+
+		if sft.Type.Kind() == reflect.Struct {
+			unflattened := func() bool {
+				origin, tag := d.fieldTag(sft)
+
+				// body, meta and unknown origin, the field tag error is reported for the struct field
+				if origin == originBody || origin == originMeta || !slices.Contains(origins, origin) {
+					return true
+				}
+
+				// named struct, e.g. `query:"user"`, see fieldConf.brackets
+				if name, _, _ := strings.Cut(tag, ","); origin == originQuery && name != "" && name != "-" {
+					return true
+				}
+
+				for _, s := range strings.Split(tag, ",") {
+					switch s {
+					case QueryStyleDeepObject, "positional", "base64json",
+						QueryStyleForm, QueryStyleSpaceDelimited, QueryStylePipeDelimited,
+						QueryStyleSemicolonDelimited, "implode":
+						return origin == originQuery
+					}
+				}
+
+				return false
+			}()
+
+			if unflattened {
+				fields = append(fields, fieldPlan{Index: sfi, Type: sft})
+			} else {
+				fields = append(fields, d.flattenFields(sft.Type, sfi)...)
+			}
+		} else {
+			fields = append(fields, fieldPlan{Index: sfi, Type: sft})
+		}
+	}
+
+	return fields
+}
+
+type fieldConf struct {
+	name     string   // query name
+	style    string   // serialization style
+	styles   []string // all serialization styles specified in the field tag
+	exploded bool     // whether exploded values
+	required bool
+	kvlist   bool // whether values are key-value pairs, e.g. "env:prod,team:core"
+	// separators of the key-value list entries and of the key and the value, e.g. "entrySep=!,pairSep=="
+	kvEntrySep, kvPairSep string
+	// whether values are assigned to struct fields in order, e.g. "?bbox=1,2,3,4"
+	positional bool
+	// time layout or one of the named formats "date" and "date-time"
+	format string
+	// whether the value is base64 encoded JSON, e.g. an opaque pagination cursor
+	base64json bool
+	// whether the value of string or []byte is URL-safe base64 encoded, e.g. a binary token
+	base64 bool
+	// whether the empty value of bool is true, e.g. "?verbose"
+	flag bool
+	// whether slice values are sorted in ascending order
+	sorted bool
+	// allowed values, e.g. "enum=open|closed"
+	enum []string
+	// value used instead of a value not in enum, e.g. "enumFallback=unknown"
+	enumFallback *string
+	// whether the name is a prefix of header names, e.g. "X-Meta-"
+	prefix bool
+	// value meaning null, e.g. "nullToken=null". The field is set to zero value (nil for pointers).
+	nullToken *string
+	// maximum length of each value in characters, e.g. "maxLen=32"
+	maxLen int
+	// regular expression every value must match, e.g. "pattern=^[a-z0-9-]+$". It must be the last setting.
+	pattern *regexp.Regexp
+	// whether the value is not split, e.g. "?ids=1,2,3" is decoded as "1,2,3"
+	raw bool
+	// whether the value is read as is from the query string, without percent-decoding
+	allowReserved bool
+	// value used when the parameter is not present, e.g. "default=20"
+	defaultValue *string
+	// inclusive bounds of numeric values, e.g. "min=1,max=100"
+	min, max *float64
+	// base of integer values, e.g. "base=16". Base 0 detects the base by the prefix, e.g. "0xff".
+	base *int
+	// delimiter of the values, e.g. "delimiter=;" for CSV body or "delimiter=::" for imploded query values
+	delimiter string
+	// dotted path of the nested JSON object decoded into the body field, e.g. "data.attributes"
+	bodyPath string
+	// whether the struct is decoded from the bracketed properties if present, e.g. "?user[name]=alex",
+	// otherwise from the query params of the struct fields, e.g. "?name=alex"
+	brackets bool
+}
+
+// decimalBase is the default base of integer values.
+const decimalBase = 10
+
+// intBase returns the base of integer values, decimal by default.
+func (c fieldConf) intBase() int {
+	if c.base == nil {
+		return decimalBase
+	}
+
+	return *c.base
+}
+
+func parseFieldTag(queryConf queryConf, tag string) (fieldConf, error) {
+	tag = strings.TrimSpace(tag)
+
+	// the pattern is the last setting, it may contain commas
+	var pattern *regexp.Regexp
+
+	if before, expr, ok := strings.Cut(tag, ",pattern="); ok {
+		// a setting after the pattern would be silently taken as a part of the expression,
+		// e.g. "required" in "pattern=^a$,required"
+		parts := strings.Split(expr, ",")
+		for _, part := range parts[1:] {
+			if _, err := parseFieldTag(queryConf, "_,"+part); err == nil {
+				return fieldConf{}, fmt.Errorf("invalid field tag '%s': setting '%s' after pattern, pattern must be last",
+					tag, strings.TrimSpace(part))
+			}
+		}
+
+		re, err := compilePattern(expr)
+		if err != nil {
+			return fieldConf{}, fmt.Errorf("invalid pattern in field tag '%s': %w", tag, err)
+		}
+
+		tag = before
+		pattern = re
+	}
+
+	parts := strings.Split(tag, ",")
+
+	if len(parts) <= 1 {
+		return fieldConf{
+			exploded: queryConf.exploded,
+			style:    queryConf.style,
+			name:     tag,
+			pattern:  pattern,
+		}, nil
+	}
+
+	conf := fieldConf{
+		exploded: queryConf.exploded,
+		style:    queryConf.style,
+		name:     strings.TrimSpace(parts[0]),
+		pattern:  pattern,
+	}
+
+	for _, part := range parts[1:] {
+		v := strings.TrimSpace(part)
+
+		if key, value, ok := strings.Cut(v, "="); ok {
+			switch key {
+			default:
+				return fieldConf{}, fmt.Errorf("invalid part '%s' in field tag '%s'", part, tag)
+			case "format":
+				conf.format = value
+			case "enum":
+				conf.enum = strings.Split(value, "|")
+			case "enumFallback":
+				conf.enumFallback = &value
+			case "nullToken":
+				conf.nullToken = &value
+			case "default":
+				conf.defaultValue = &value
+			case "min", "max":
+				bound, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return fieldConf{}, fmt.Errorf("invalid part '%s' in field tag '%s'", part, tag)
+				}
+
+				if key == "min" {
+					conf.min = &bound
+				} else {
+					conf.max = &bound
+				}
+			case "base":
+				base, err := strconv.Atoi(value)
+				if err != nil || base == 1 || base < 0 || base > 36 {
+					return fieldConf{}, fmt.Errorf("invalid part '%s' in field tag '%s'", part, tag)
+				}
+
+				conf.base = &base
+			case "delimiter":
+				if value == "" {
+					return fieldConf{}, fmt.Errorf("invalid part '%s' in field tag '%s'", part, tag)
+				}
+
+				conf.delimiter = value
+				// implicitly implode if delimiter is specified
+				conf.exploded = false
+			case "entrySep", "pairSep":
+				if value == "" {
+					return fieldConf{}, fmt.Errorf("invalid part '%s' in field tag '%s'", part, tag)
+				}
+
+				if key == "entrySep" {
+					conf.kvEntrySep = value
+				} else {
+					conf.kvPairSep = value
+				}
+
+				// implicitly key-value list if separator is specified
+				conf.kvlist = true
+			case "maxLen":
+				n, err := strconv.Atoi(value)
+				if err != nil || n < 0 {
+					return fieldConf{}, fmt.Errorf("invalid part '%s' in field tag '%s'", part, tag)
+				}
+
+				conf.maxLen = n
+			}
+
+			continue
+		}
+
+		switch v {
+		default:
+			return fieldConf{}, fmt.Errorf("invalid part '%s' in field tag '%s'", part, tag)
+		case "required":
+			conf.required = true
+		case "explode":
+			conf.exploded = true
+		case "implode":
+			conf.exploded = false
+		case "kvlist":
+			conf.kvlist = true
+		case "prefix":
+			conf.prefix = true
+		case "sorted":
+			conf.sorted = true
+		case "raw":
+			conf.raw = true
+		case "allowReserved":
+			conf.allowReserved = true
+		case "base64json":
+			conf.base64json = true
+		case "base64":
+			conf.base64 = true
+		case "flag":
+			conf.flag = true
+		case "positional":
+			conf.positional = true
+			// implicitly implode positional values
+			conf.exploded = false
+		case QueryStyleForm, QueryStylePipeDelimited, QueryStyleSpaceDelimited, QueryStyleSemicolonDelimited:
+			conf.style = v
+			conf.styles = append(conf.styles, v)
+			// implicitly implode if style is specified
+			conf.exploded = false
+		case QueryStyleDeepObject, PathStyleSimple, PathStyleLabel, PathStyleMatrix:
+			conf.style = v
+		}
+	}
+
+	return conf, nil
+}
+
+// parseBodyTag parses the body field tag having the body format and the settings, e.g. "json,required".
+// The format is the parameter name of the body. In the combined field tag, the name followed by the format
+// is the path of the nested JSON object, e.g. "data.attributes,json" for `oas:"data.attributes,body,json"`.
+func parseBodyTag(tag string) (fieldConf, error) {
+	parts := strings.Split(strings.TrimSpace(tag), ",")
+	conf := fieldConf{name: strings.TrimSpace(parts[0])}
+
+	for _, part := range parts[1:] {
+		if v := strings.TrimSpace(part); conf.name != "" && conf.bodyPath == "" && isBodyFormat(v) {
+			conf.bodyPath, conf.name = conf.name, v
+			continue
+		}
+
+		switch v := strings.TrimSpace(part); {
+		case v == "required":
+			conf.required = true
+		case strings.HasPrefix(v, "delimiter="):
+			// CSV delimiter, e.g. "delimiter=;"
+			conf.delimiter = strings.TrimPrefix(v, "delimiter=")
+
+			if utf8.RuneCountInString(conf.delimiter) != 1 {
+				return fieldConf{}, fmt.Errorf("invalid part '%s' in field tag '%s'", part, tag)
+			}
+		case conf.name == "" && v != "":
+			// format after the origin in the combined field tag, e.g. `oas:",body,json"`
+			conf.name = v
+		default:
+			return fieldConf{}, fmt.Errorf("invalid part '%s' in field tag '%s'", part, tag)
+		}
+	}
+
+	return conf, nil
+}
+
+// isBodyFormat reports whether the part of the body field tag is the body format, not a setting.
+func isBodyFormat(part string) bool {
+	return part != "" && part != "required" && !strings.Contains(part, "=")
+}
+
+// isBool reports whether the type (or the type it points to) is bool.
+func isBool(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	return t.Kind() == reflect.Bool
+}
+
+// isBase64Type reports whether the type (or the type it points to) is decoded from base64 encoded values -
+// a string, a slice of bytes, or a slice or an array of them.
+func isBase64Type(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if isSlice(t) {
+		t = t.Elem()
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+	}
+
+	return t.Kind() == reflect.String || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8
+}
+
+// trimSpaces returns a copy of the values without leading and trailing white space.
+func trimSpaces(values []string) []string {
+	trimmed := make([]string, len(values))
+
+	for i, v := range values {
+		trimmed[i] = strings.TrimSpace(v)
+	}
+
+	return trimmed
+}
+
+// parseQueryValuesDeep returns values of the deep object properties by the property name,
+// e.g. "?filter[status]=open&filter[tags][]=a&filter[tags][]=b".
+func parseQueryValuesDeep(name string, query queryValues) queryValues {
+	values := queryValues{caseSensitive: query.caseSensitive}
+
+	for _, k := range query.names {
+		// array property, e.g. "filter[tags][]"
+		key, _ := strings.CutSuffix(k, "[]")
+
+		if len(key) <= len(name) || key[len(name)] != '[' || !query.equal(key[:len(name)], name) {
+			continue
+		}
+
+		rest := key[len(name)+1:]
+
+		// nested property, e.g. "user[role]" for "filter[user][role]"
+		propName, nested, ok := strings.Cut(rest, "]")
+		if !ok || nested != "" && !strings.HasPrefix(nested, "[") {
+			continue
+		}
+
+		propName += nested
+
+		v, _ := query.get(k)
+		values.add(propName, v...)
+	}
+
+	return values
+}
+
+// queryValues contains query params looked up by the case-insensitive name.
+// The values of the differently cased names are merged in the order of the query string,
+// e.g. "?id=1&ID=2&id=3" is [1 2 3].
+type queryValues struct {
+	// names are the param names in the order of the first occurrence, spelled as in the query string
+	names []string
+	// values by the lowercased param name, or the exact name if case-sensitive
+	values map[string][]string
+	// rawQuery is the query string, used to read values not percent-decoded
+	rawQuery string
+	// caseSensitive reports whether the names are matched exactly, see [request.CaseSensitiveQuery]
+	caseSensitive bool
+}
+
+// parseQuery parses the query string keeping the order of the values. Similar to [url.ParseQuery],
+// invalid params are skipped.
+func parseQuery(rawQuery string, caseSensitive bool) queryValues {
+	query := queryValues{rawQuery: rawQuery, caseSensitive: caseSensitive}
+
+	for rawQuery != "" {
+		var param string
+
+		param, rawQuery, _ = strings.Cut(rawQuery, "&")
+		if param == "" || strings.Contains(param, ";") {
+			continue
+		}
+
+		name, value, _ := strings.Cut(param, "=")
+
+		name, err := url.QueryUnescape(name)
+		if err != nil {
+			continue
+		}
+
+		value, err = url.QueryUnescape(value)
+		if err != nil {
+			continue
+		}
+
+		query.add(name, value)
+	}
+
+	return query
+}
+
+// add appends the values of the param.
+func (q *queryValues) add(name string, values ...string) {
+	if q.values == nil {
+		q.values = make(map[string][]string)
+	}
+
+	key := q.key(name)
+
+	if _, ok := q.values[key]; !ok {
+		q.names = append(q.names, name)
+	}
+
+	q.values[key] = append(q.values[key], values...)
+}
+
+// get returns the values of the param by the case-insensitive name.
+func (q queryValues) get(name string) ([]string, bool) {
+	values, ok := q.values[q.key(name)]
+
+	return values, ok
+}
+
+// key returns the key of the param values.
+func (q queryValues) key(name string) string {
+	if q.caseSensitive {
+		return name
+	}
+
+	return strings.ToLower(name)
+}
+
+// equal reports whether the param names match.
+func (q queryValues) equal(a, b string) bool {
+	if q.caseSensitive {
+		return a == b
+	}
+
+	return strings.EqualFold(a, b)
+}
+
+// lookup returns the values of the field param. The values are not percent-decoded
+// when the field allows reserved characters.
+//
+// The params having semicolons are ignored, same as [url.ParseQuery] does. The values of
+// the semicolon delimited field may have semicolons, e.g. "?ids=1;2;3".
+func (q queryValues) lookup(conf fieldConf) ([]string, bool) {
+	semicolons := !conf.exploded && (conf.style == QueryStyleSemicolonDelimited ||
+		slices.Contains(conf.styles, QueryStyleSemicolonDelimited) || strings.Contains(conf.delimiter, ";"))
+
+	if !conf.allowReserved && !semicolons {
+		return q.get(conf.name)
+	}
+
+	var values []string
+
+	for rawQuery := q.rawQuery; rawQuery != ""; {
+		var param string
+
+		param, rawQuery, _ = strings.Cut(rawQuery, "&")
+		if param == "" {
+			continue
+		}
+
+		name, value, _ := strings.Cut(param, "=")
+		if strings.Contains(name, ";") || !semicolons && strings.Contains(value, ";") {
+			continue
+		}
+
+		if name, err := url.QueryUnescape(name); err != nil || !q.equal(name, conf.name) {
+			continue
+		}
+
+		if !conf.allowReserved {
+			var err error
+
+			if value, err = url.QueryUnescape(value); err != nil {
+				continue
+			}
+		}
+
+		values = append(values, value)
+	}
+
+	return values, values != nil
+}
+
+// parseQueryValues parses query parameters as defined in field tag.
+func parseQueryValues(conf fieldConf, query queryValues) ([]string, bool) {
+	values, ok := query.lookup(conf)
+	if !ok {
+		return nil, false
+	}
+
+	if conf.exploded || len(values) == 0 {
+		return values, true
+	}
+
+	// Query is imploded. Always read the last value when expected imploded query, but received exploded - "?v=1&v=2".
+	last := values[len(values)-1]
+
+	// Multiple styles. Split by any of the delimiters.
+	if len(conf.styles) > 1 && conf.delimiter == "" {
+		delimiters := make([]string, 0, len(conf.styles))
+		for _, style := range conf.styles {
+			delimiters = append(delimiters, queryDelimiter(style))
+		}
+
+		return splitAny(last, delimiters), true
+	}
+
+	return strings.Split(last, conf.valueDelimiter()), true
+}
+
+// defaultValues returns the default values of the field. The default values of slices are
+// separated by the style delimiter or "|" for the form style, e.g. "default=a|b|c".
+func defaultValues(conf fieldConf, t reflect.Type) []string {
+	if !isSlice(t) {
+		return []string{*conf.defaultValue}
+	}
+
+	delimiter := conf.valueDelimiter()
+	if delimiter == "," {
+		// comma separates the settings of the field tag
+		delimiter = "|"
+	}
+
+	return strings.Split(*conf.defaultValue, delimiter)
+}
+
+// kvSeparators returns the key-value list separators of the field tag, or the separators of the decoder.
+func (c fieldConf) kvSeparators(query queryConf) (string, string) {
+	entrySep, pairSep := query.kvEntrySep, query.kvPairSep
+
+	if c.kvEntrySep != "" {
+		entrySep = c.kvEntrySep
+	}
+
+	if c.kvPairSep != "" {
+		pairSep = c.kvPairSep
+	}
+
+	return entrySep, pairSep
+}
+
+// valueDelimiter returns the delimiter of imploded values, the delimiter in the field tag
+// or the delimiter of the serialization style.
+func (c fieldConf) valueDelimiter() string {
+	if c.delimiter != "" {
+		return c.delimiter
+	}
+
+	return queryDelimiter(c.style)
+}
+
+// queryDelimiter returns the delimiter of imploded values in the serialization style.
+func queryDelimiter(style string) string {
+	switch style {
+	default:
+		return ","
+	case QueryStyleSpaceDelimited:
+		return " "
+	case QueryStylePipeDelimited:
+		return "|"
+	case QueryStyleSemicolonDelimited:
+		return ";"
+	}
+}
+
+// splitAny slices s into all substrings separated by any of the delimiters.
+func splitAny(s string, delimiters []string) []string {
+	var values []string
+
+	for {
+		i, size := -1, 0
+
+		for _, delimiter := range delimiters {
+			if j := strings.Index(s, delimiter); j >= 0 && (i < 0 || j < i) {
+				i, size = j, len(delimiter)
+			}
+		}
+
+		if i < 0 {
+			return append(values, s)
+		}
+
+		values = append(values, s[:i])
+		s = s[i+size:]
+	}
+}
+
+// bodyFormat returns the body format by the "Content-Type" request header, "json" if the header
+// is absent or invalid. Media types with "+json" and "+xml" suffixes are decoded as JSON and XML,
+// e.g. "application/vnd.api+json".
+func (d Decoder) bodyFormat(r *http.Request) (string, error) {
+	contentType := r.Header.Get("Content-Type")
+
+	// ignore parameters, e.g. "application/json; charset=utf-8"
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "json", nil //nolint:nilerr
+	}
+
+	for format, dec := range d.bodyDecoders {
+		for _, v := range dec.mediaTypes {
+			if mediaType == strings.ToLower(v) {
+				return format, nil
+			}
+		}
+	}
+
+	switch {
+	default:
+		return "", UnsupportedMediaTypeError{MediaType: contentType}
+	case mediaType == "application/json", strings.HasSuffix(mediaType, "+json"):
+		return "json", nil
+	case mediaType == "application/xml", mediaType == "text/xml", strings.HasSuffix(mediaType, "+xml"):
+		return "xml", nil
+	case mediaType == "application/yaml", mediaType == "application/x-yaml":
+		return "yaml", nil
+	case mediaType == "text/csv":
+		return "csv", nil
+	case mediaType == "multipart/form-data":
+		return "multipart", nil
+	}
+}
+
+func (d Decoder) decodeBody(r *http.Request, body io.Reader, conf fieldConf, i interface{}) error {
+	format := conf.name
+	if format == "" {
+		var err error
+
+		if format, err = d.bodyFormat(r); err != nil {
+			return err
+		}
+	}
+
+	if conf.bodyPath != "" && format != "json" {
+		return fmt.Errorf(`body path '%s' requires "json" format, got "%s"`, conf.bodyPath, format)
+	}
+
+	if dec, ok := d.bodyDecoders[format]; ok {
+		// allocate pointers so that the decoder receives a pointer to the target, e.g. *Message instead of **Message
+		rv := reflect.ValueOf(i).Elem()
+
+		for rv.Kind() == reflect.Ptr {
+			if rv.IsNil() {
+				rv.Set(reflect.New(rv.Type().Elem()))
+			}
+
+			rv = rv.Elem()
+		}
+
+		if err := dec.decode(body, rv.Addr().Interface()); err != nil {
+			return fmt.Errorf("decode %s body: %w", format, err)
+		}
+
+		return nil
+	}
+
+	switch format {
+	default:
+		return fmt.Errorf(`unsupported body format "%s" in field tag`, format)
+	case "multipart":
+		return d.decodeMultipart(r, reflect.ValueOf(i).Elem())
+	case "json":
+		if conf.bodyPath != "" {
+			data, err := jsonBodyPath(body, conf.bodyPath)
+			if err != nil {
+				return err
+			}
+
+			body = bytes.NewReader(data)
+		}
+
+		return d.decodeJSONBody(body, i)
+	case "text":
+		return decodeTextBody(body, reflect.ValueOf(i).Elem())
+	case "csv":
+		return d.decodeCSVBody(body, reflect.ValueOf(i).Elem(), conf)
+	case "xml":
+		err := d.newXMLDecoder(body).Decode(i)
+		if err == io.EOF { //nolint:errorlint
+			return errEmptyBody
+		}
+
+		if err != nil {
+			return fmt.Errorf("decode XML body: %w", err)
+		}
+
+		return nil
+	case "yaml":
+		if d.yamlUnmarshal == nil {
+			return errors.New("decode YAML body: no unmarshaler, use request.YAMLUnmarshaler option")
+		}
+
+		data, err := io.ReadAll(body)
+		if err != nil {
+			return fmt.Errorf("read body: %w", err)
+		}
+
+		if len(data) == 0 {
+			return errEmptyBody
+		}
+
+		if err := d.yamlUnmarshal(data, i); err != nil {
+			return fmt.Errorf("decode YAML body: %w", err)
+		}
+
+		return nil
+	}
+}
+
+// decodeCSVBody decodes CSV body into a slice of structs. The first record is the header, the columns
+// are matched case-insensitively to the struct fields by the name in the field tag, json field tag
+// or the field name. Empty values and unknown columns are skipped.
+func (d Decoder) decodeCSVBody(body io.Reader, rv reflect.Value, conf fieldConf) error {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Slice {
+		return fmt.Errorf("decode CSV body: want slice of structs, got %s: %w", rv.Type(),
+			UnsupportedTypeError{Kind: rv.Kind()})
+	}
+
+	elemType := rv.Type().Elem()
+	for elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+
+	if elemType.Kind() != reflect.Struct {
+		return fmt.Errorf("decode CSV body: want slice of structs, got %s: %w", rv.Type(),
+			UnsupportedTypeError{Kind: elemType.Kind()})
+	}
+
+	cr := csv.NewReader(body)
+	cr.ReuseRecord = true
+
+	if conf.delimiter != "" {
+		cr.Comma, _ = utf8.DecodeRuneInString(conf.delimiter)
+	}
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return errEmptyBody
+	}
+
+	if err != nil {
+		return fmt.Errorf("decode CSV body: %w", err)
+	}
+
+	// struct field index and configuration by the column index
+	type column struct {
+		name  string
+		index int
+		conf  fieldConf
+	}
+
+	columns := make([]*column, len(header))
+
+	for i := range elemType.NumField() {
+		sft := elemType.Field(i)
+		if !sft.IsExported() {
+			continue
+		}
+
+		fieldConf, err := d.deepPropertyConf(sft)
+		if err != nil {
+			return err
+		}
+
+		for j, name := range header {
+			if columns[j] == nil && strings.EqualFold(strings.TrimSpace(name), fieldConf.name) {
+				columns[j] = &column{name: name, index: i, conf: fieldConf}
+			}
+		}
+	}
+
+	slice := reflect.MakeSlice(rv.Type(), 0, 0)
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return fmt.Errorf("decode CSV body: %w", err)
+		}
+
+		elem := reflect.New(rv.Type().Elem()).Elem()
+		sv := elem
+
+		for sv.Kind() == reflect.Ptr {
+			sv.Set(reflect.New(sv.Type().Elem()))
+			sv = sv.Elem()
+		}
+
+		for j, value := range record {
+			if columns[j] == nil || value == "" {
+				continue
+			}
+
+			if err := d.setValue(sv.Field(columns[j].index), []string{value}, columns[j].conf); err != nil {
+				line, _ := cr.FieldPos(j)
+
+				return fmt.Errorf("decode CSV body: line %d, column '%s': %w", line, columns[j].name, err)
+			}
+		}
+
+		slice = reflect.Append(slice, elem)
+	}
+
+	rv.Set(slice)
+
+	return nil
+}
+
+// decodeTextBody reads the whole body into a string, a slice of bytes or [encoding.TextUnmarshaler].
+func decodeTextBody(body io.Reader, rv reflect.Value) error {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("read body: %w", err)
+	}
+
+	if len(data) == 0 {
+		return errEmptyBody
+	}
+
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		rv = rv.Elem()
+	}
+
+	if u, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := u.UnmarshalText(data); err != nil {
+			return fmt.Errorf("decode text body: %w", err)
+		}
+
+		return nil
+	}
+
+	switch {
+	case rv.Kind() == reflect.String:
+		rv.SetString(string(data))
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8:
+		rv.SetBytes(data)
+	default:
+		return fmt.Errorf("decode text body: want string or []byte, got %s", rv.Type())
+	}
+
+	return nil
+}
+
+// decodeJSONBody decodes JSON body into i. The interface targets having a registered discriminator
+// are decoded into the concrete type returned by the discriminator.
+func (d Decoder) decodeJSONBody(body io.Reader, i interface{}) error {
+	rv := reflect.ValueOf(i).Elem()
+
+	discriminate, ok := d.discriminators[rv.Type()]
+	if !ok {
+		return jsonBodyError(d.newJSONDecoder(body).Decode(i))
+	}
+
+	var data json.RawMessage
+	if err := json.NewDecoder(body).Decode(&data); err != nil {
+		return jsonBodyError(err)
+	}
+
+	concrete, err := discriminate(data)
+	if err != nil {
+		return fmt.Errorf("decode JSON body: %w", err)
+	}
+
+	cv := reflect.ValueOf(concrete)
+	if cv.Kind() != reflect.Ptr || cv.IsNil() || !cv.Type().AssignableTo(rv.Type()) {
+		return fmt.Errorf("decode JSON body: discriminator of %s returned %T", rv.Type(), concrete)
+	}
+
+	if err := jsonBodyError(d.newJSONDecoder(bytes.NewReader(data)).Decode(concrete)); err != nil {
+		return err
+	}
+
+	rv.Set(cv)
+
+	return nil
+}
+
+// newXMLDecoder returns XML decoder configured by the decoder options.
+func (d Decoder) newXMLDecoder(r io.Reader) *xml.Decoder {
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = d.xmlCharsetReader
+	dec.DefaultSpace = d.xmlDefaultSpace
+
+	if d.xmlNonStrict {
+		dec.Strict = false
+		dec.AutoClose = xml.HTMLAutoClose
+		dec.Entity = xml.HTMLEntity
+	}
+
+	return dec
+}
+
+// jsonBodyPath returns the nested JSON value by the dotted path, e.g. the attributes object
+// of {"data":{"attributes":{...}}} by the path "data.attributes". The absent or null value is an empty body.
+func jsonBodyPath(body io.Reader, path string) (json.RawMessage, error) {
+	var data json.RawMessage
+	if err := json.NewDecoder(body).Decode(&data); err != nil {
+		return nil, jsonBodyError(err)
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(data, &object); err != nil {
+			return nil, fmt.Errorf("decode JSON body: path '%s': %w", path, err)
+		}
+
+		var ok bool
+
+		if data, ok = object[key]; !ok || bytes.Equal(data, []byte("null")) {
+			return nil, errEmptyBody
+		}
+	}
+
+	return data, nil
+}
+
+// newJSONDecoder returns JSON decoder configured by the decoder options.
+func (d Decoder) newJSONDecoder(r io.Reader) *json.Decoder {
+	dec := json.NewDecoder(r)
+	if d.useNumber {
+		dec.UseNumber()
+	}
+
+	if d.disallowUnknown {
+		dec.DisallowUnknownFields()
+	}
+
+	return dec
+}
+
+// jsonBodyError returns the error of decoding JSON body.
+func jsonBodyError(err error) error {
+	if err == io.EOF { //nolint:errorlint
+		return errEmptyBody
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return fmt.Errorf("decode JSON body: %w", jsonTypeError{typeErr})
+	}
+
+	// encoding/json does not export the error of unknown field
+	if name, ok := strings.CutPrefix(fmt.Sprint(err), "json: unknown field "); ok {
+		return fmt.Errorf("decode JSON body: %w %s", ErrUnknownField, name)
+	}
+
+	if err != nil {
+		return fmt.Errorf("decode JSON body: %w", err)
+	}
+
+	return nil
+}
+
+func (d Decoder) decodeCookie(r *http.Request, fv reflect.Value, conf fieldConf) (bool, error) {
+	cookie, err := r.Cookie(conf.name)
+	if errors.Is(err, http.ErrNoCookie) {
+		if conf.required {
+			return false, RequiredError{Origin: originCookie, Name: conf.name}
+		}
+
+		return false, nil
+	}
+
+	if err != nil {
+		return false, DecodeError{Origin: originCookie, Name: conf.name, Err: err}
+	}
+
+	err = d.setValue(fv, []string{cookie.Value}, conf)
+	if err == nil {
+		err = checkRange(conf, fv)
+	}
+
+	if err != nil {
+		return true, DecodeError{Origin: originCookie, Name: conf.name, Err: err}
+	}
+
+	return true, nil
+}
+
+var (
+	fileHeaderType      = reflect.TypeFor[*multipart.FileHeader]()
+	fileHeaderSliceType = reflect.TypeFor[[]*multipart.FileHeader]()
+)
+
+// decodeMultipart decodes multipart/form-data body into struct fields. Fields of type *multipart.FileHeader
+// or []*multipart.FileHeader are set to the files of the form part, other fields are set to the form values.
+func (d Decoder) decodeMultipart(r *http.Request, rv reflect.Value) error {
+	if err := r.ParseMultipartForm(d.multipartMaxMemory); err != nil {
+		return fmt.Errorf("parse multipart form: %w", err)
+	}
+
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return errors.New("expected struct for multipart body")
+	}
+
+	for i := range rv.NumField() {
+		sfv := rv.Field(i)
+		sft := rv.Type().Field(i)
+
+		if !sft.IsExported() {
+			continue
+		}
+
+		name := sft.Tag.Get("form")
+
+		switch name {
+		case "-":
+			continue
+		case "":
+			name = sft.Name
+		}
+
+		switch sfv.Type() {
+		case fileHeaderType:
+			if files := lookupFold(r.MultipartForm.File, name); len(files) > 0 {
+				sfv.Set(reflect.ValueOf(files[0]))
+			}
+		case fileHeaderSliceType:
+			if files := lookupFold(r.MultipartForm.File, name); len(files) > 0 {
+				sfv.Set(reflect.ValueOf(files))
+			}
+		default:
+			if err := d.setValue(sfv, lookupFold(r.MultipartForm.Value, name), fieldConf{}); err != nil {
+				return fmt.Errorf("multipart field '%s': %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// lookupFold returns the value of the key, matching the key case-insensitively if no exact match.
+func lookupFold[T any](m map[string]T, key string) T {
+	if v, ok := m[key]; ok {
+		return v
+	}
+
+	for k, v := range m {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+
+	var zero T
+
+	return zero
+}
+
+// decodeCSRF reads CSRF token from the request header or the form field and validates it
+// using [request.CSRFValidator].
+func (d Decoder) decodeCSRF(r *http.Request, fv reflect.Value, conf fieldConf) (bool, error) {
+	name := conf.name
+
+	token := r.Header.Get(name)
+	if token == "" {
+		token = r.PostFormValue(name)
+	}
+
+	// fail closed, the token is not accepted without validation
+	if d.csrfValidator == nil {
+		return token != "", fmt.Errorf("%w: csrf field without validator, use request.CSRFValidator option",
+			ErrInvalidCSRFToken)
+	}
+
+	if err := d.csrfValidator(r, token); err != nil {
+		return token != "", fmt.Errorf("%w: %w", ErrInvalidCSRFToken, err)
+	}
+
+	if err := d.setValue(fv, []string{token}, fieldConf{}); err != nil {
+		return true, DecodeError{Origin: originCSRF, Name: name, Err: err}
+	}
+
+	return token != "", nil
+}
+
+// jsonTypeError describes a JSON value not appropriate for the Go type
+// using the path of the JSON field, e.g. "field 'item.count': want int, got number 5.5".
+type jsonTypeError struct {
+	err *json.UnmarshalTypeError
+}
+
+func (e jsonTypeError) Error() string {
+	if e.err.Field == "" {
+		return fmt.Sprintf("want %s, got %s", e.err.Type, e.err.Value)
+	}
+
+	return fmt.Sprintf("field '%s': want %s, got %s", e.err.Field, e.err.Type, e.err.Value)
+}
+
+func (e jsonTypeError) Unwrap() error {
+	return e.err
+}
+
+func (d Decoder) decodeHeader(r *http.Request, fv reflect.Value, conf fieldConf) (bool, error) {
+	if !conf.prefix {
+		name := http.CanonicalHeaderKey(conf.name)
+
+		values := r.Header.Values(name)
+		if len(values) == 0 {
+			if conf.required {
+				return false, RequiredError{Origin: originHeader, Name: name}
+			}
+
+			return false, nil
+		}
+
+		// simple style array, e.g. "Accept-Encoding: gzip, deflate, br"
+		if isSlice(fv.Type()) {
+			values = splitHeaderValues(values)
+
+			if len(values) == 0 {
+				setEmptySlice(fv)
+				return true, nil
+			}
+		}
+
+		err := d.setValue(fv, values, conf)
+		if err == nil {
+			err = checkRange(conf, fv)
+		}
+
+		if err != nil {
+			return true, DecodeError{Origin: originHeader, Name: name, Err: err}
+		}
+
+		return true, nil
+	}
+
+	// all headers having the prefix, e.g. "X-Meta-Foo" and "X-Meta-Bar" for prefix "X-Meta-"
+	prefix := http.CanonicalHeaderKey(conf.name)
+	values := make(map[string][]string)
+
+	for k, v := range r.Header {
+		if name, ok := strings.CutPrefix(http.CanonicalHeaderKey(k), prefix); ok && name != "" {
+			values[name] = v
+		}
+	}
+
+	if len(values) == 0 {
+		if conf.required {
+			return false, RequiredError{Origin: originHeader, Name: prefix}
+		}
+
+		return false, nil
+	}
+
+	if err := d.setMapValue(fv, values, conf); err != nil {
+		return true, DecodeError{Origin: originHeader, Name: prefix, Err: err}
+	}
+
+	return true, nil
+}
+
+// splitHeaderValues splits comma-separated values of all header lines, empty values are omitted.
+func splitHeaderValues(values []string) []string {
+	var split []string
+
+	for _, value := range values {
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				split = append(split, v)
+			}
+		}
+	}
+
+	return split
+}
+
+// parseBool parses the boolean value, accepting the literals of [request.BoolStrings] option.
+func (d Decoder) parseBool(value string) (bool, error) {
+	equal := func(s string) bool { return strings.EqualFold(s, value) }
+
+	switch {
+	case slices.ContainsFunc(d.boolTrue, equal):
+		return true, nil
+	case slices.ContainsFunc(d.boolFalse, equal):
+		return false, nil
+	}
+
+	v, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, err //nolint:wrapcheck
+	}
+
+	return v, nil
+}
+
+// setEmptySlice sets an empty slice or a zero array allocating pointers.
+func setEmptySlice(rv reflect.Value) {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() == reflect.Array {
+		rv.SetZero()
+		return
+	}
+
+	rv.Set(reflect.MakeSlice(rv.Type(), 0, 0))
+}
+
+// setMapValue sets map entries converting keys and values to the map key and element types.
+func (d Decoder) setMapValue(rv reflect.Value, values map[string][]string, conf fieldConf) error {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Map {
+		return errors.New("expected map")
+	}
+
+	t := rv.Type()
+
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(t))
+	}
+
+	for k, v := range values {
+		key := reflect.New(t.Key()).Elem()
+		if err := d.setValue(key, []string{k}, conf); err != nil {
+			return err
+		}
+
+		elem := reflect.New(t.Elem()).Elem()
+		if err := d.setValue(elem, v, conf); err != nil {
+			return err
+		}
+
+		rv.SetMapIndex(key, elem)
+	}
+
+	return nil
+}
+
+func (d Decoder) decodeQuery(fv reflect.Value, conf fieldConf, query queryValues) (bool, error) {
+	// ignore
+	if conf.name == "-" {
+		return false, nil
+	}
+
+	// deep object
+	if conf.style == QueryStyleDeepObject {
+		qv := parseQueryValuesDeep(conf.name, query)
+		if len(qv.names) == 0 {
+			if conf.required {
+				return false, RequiredError{Origin: originQuery, Name: conf.name}
+			}
+
+			return false, nil
+		}
+
+		if err := d.setDeepValue(fv, qv); err != nil {
+			return true, DecodeError{Origin: originQuery, Name: conf.name, Err: err}
+		}
+
+		return true, nil
+	}
+
+	// raw values
+	if conf.raw {
+		qv, ok := query.lookup(conf)
+		if !ok {
+			if conf.required {
+				return false, RequiredError{Origin: originQuery, Name: conf.name}
+			}
+
+			return false, nil
+		}
+
+		if err := d.setValue(fv, qv, conf); err != nil {
+			return true, DecodeError{Origin: originQuery, Name: conf.name, Err: err}
+		}
+
+		return true, nil
+	}
+
+	// key-value list
+	if conf.kvlist {
+		qv, ok := query.lookup(conf)
+		if !ok {
+			if conf.required {
+				return false, RequiredError{Origin: originQuery, Name: conf.name}
+			}
+
+			return false, nil
+		}
+
+		if err := d.setKVListValue(fv, qv, conf); err != nil {
+			return true, DecodeError{Origin: originQuery, Name: conf.name, Err: err}
+		}
+
+		return true, nil
+	}
+
+	// normal query
+	qv, ok := parseQueryValues(conf, query)
+	if !ok {
+		if conf.required {
+			return false, RequiredError{Origin: originQuery, Name: conf.name}
+		}
+
+		if conf.defaultValue != nil {
+			if err := d.setValue(fv, defaultValues(conf, fv.Type()), conf); err != nil {
+				return false, DecodeError{Origin: originQuery, Name: conf.name, Err: fmt.Errorf("default value: %w", err)}
+			}
+
+			return false, nil
+		}
+
+		if len(qv) == 0 {
+			return false, nil
+		}
+	}
+
+	// expected exploded, but received imploded - "?ids=1,2,3"
+	if d.lenientArrays && conf.exploded && len(qv) == 1 && isSlice(fv.Type()) {
+		qv = strings.Split(qv[0], conf.valueDelimiter())
+	}
+
+	if d.trimSpace {
+		qv = trimSpaces(qv)
+	}
+
+	// empty list, e.g. "?ids=" with the field tag `query:"ids,form"`,
+	// the single empty value is kept for a slice of elements decoded from strings, e.g. []*string
+	if !conf.exploded && len(qv) == 1 && qv[0] == "" && isSlice(fv.Type()) && !d.isStringSlice(fv.Type()) {
+		setEmptySlice(fv)
+		return true, nil
+	}
+
+	// explicit null, e.g. "?bio=null"
+	if conf.nullToken != nil && len(qv) == 1 && qv[0] == *conf.nullToken {
+		fv.Set(reflect.Zero(fv.Type()))
+		return true, nil
+	}
+
+	var err error
+
+	if qv, err = checkEnum(conf, qv); err != nil {
+		return true, DecodeError{Origin: originQuery, Name: conf.name, Err: err}
+	}
+
+	if err = checkMaxLen(conf, qv); err != nil {
+		return true, DecodeError{Origin: originQuery, Name: conf.name, Err: err}
+	}
+
+	if err = checkPattern(conf, qv); err != nil {
+		return true, DecodeError{Origin: originQuery, Name: conf.name, Err: err}
+	}
+
+	if d.rejectMultiForScalar && len(qv) > 1 && !conf.positional && !d.isMultiValue(fv.Type()) {
+		return true, DecodeError{
+			Origin: originQuery,
+			Name:   conf.name,
+			Err:    fmt.Errorf("want single value, got %d", len(qv)),
+		}
+	}
+
+	switch {
+	case conf.positional:
+		err = d.setPositionalValue(fv, qv, conf)
+	case conf.base64json:
+		err = setBase64JSONValue(fv, qv)
+	case d.isDeepObject(fv.Type()):
+		err = d.setFormObjectValue(fv, qv)
+	default:
+		err = d.setValue(fv, qv, conf)
+	}
+
+	if err == nil && conf.sorted {
+		err = sortSlice(fv)
+	}
+
+	if err == nil {
+		err = checkRange(conf, fv)
+	}
+
+	if err != nil {
+		return true, DecodeError{Origin: originQuery, Name: conf.name, Err: err}
+	}
+
+	return true, nil
 }
 
-// flattenFields flattens all fields of struct, the following fields are not flattened:
-// - fields having "body" field tag;
-// - fields having "query" field tag with "deepObject" serialization;
-// - fields having encoding.TextUnmarshaler interface.
-func flattenFields(v reflect.Value) []field {
-	ft := v.Type()
+func (d Decoder) setValue(rv reflect.Value, values []string, conf fieldConf) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	if d.trimSpace {
+		values = trimSpaces(values)
+	}
+
+	// allocate the pointer after decoding, the pointer stays nil if the value is invalid, e.g. *[]int
+	if rv.Kind() == reflect.Ptr {
+		if !rv.IsNil() {
+			return d.setValue(rv.Elem(), values, conf)
+		}
 
-	fields := make([]field, 0, ft.NumField())
+		v := reflect.New(rv.Type().Elem())
+		if err := d.setValue(v.Elem(), values, conf); err != nil {
+			return err
+		}
 
-	for i := range ft.NumField() {
-		sfv := v.Field(i)
-		sft := ft.Field(i)
+		rv.Set(v)
 
-		// NOTE: ignore unexported fields in struct.
-		if !sft.IsExported() {
-			continue
+		return nil
+	}
+
+	if decode, ok := d.decoders[rv.Type()]; ok {
+		ctx := d.ctx
+		if ctx == nil {
+			ctx = context.Background()
 		}
 
-		if _, ok := sfv.Addr().Interface().(encoding.TextUnmarshaler); ok {
-			fields = append(fields, field{Value: sfv, Type: sft})
-			continue
+		v, err := decode(ctx, values[0])
+		if err != nil {
+			return err //nolint:wrapcheck
 		}
 
-		if sfv.Kind() == reflect.Struct {
-			deepQueryOrBody := func() bool {
-				for _, s := range strings.Split(sft.Tag.Get("query"), ",") {
-					if s == QueryStyleDeepObject {
-						return true
-					}
+		dv := reflect.ValueOf(v)
+		if !dv.IsValid() || dv.Type() != rv.Type() {
+			return fmt.Errorf("decoder of %s returned %T", rv.Type(), v)
+		}
+
+		rv.Set(dv)
+
+		return nil
+	}
+
+	if flags, ok := d.flags[rv.Type()]; ok {
+		return setFlagsValue(rv, flags, values)
+	}
+
+	const bitsPerByte = 8
+
+	bitSize := func() int { return int(rv.Type().Size()) * bitsPerByte }
+
+	value := values[0]
+
+	if rv.Type() == timeType {
+		return d.setTimeValue(rv, value, conf.format)
+	}
+
+	if e, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		if err := e.UnmarshalText([]byte(value)); err != nil {
+			return fmt.Errorf("set values %v: %w", values, err)
+		}
+
+		return nil
+	}
+
+	isBytes := rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8
+
+	if conf.base64 && (rv.Kind() == reflect.String || isBytes) {
+		b, err := decodeBase64(value)
+		if err != nil {
+			return fmt.Errorf("decode base64: %w", err)
+		}
+
+		value = string(b)
+	}
+
+	switch kind := rv.Kind(); kind { //nolint:exhaustive
+	default:
+		return UnsupportedTypeError{Kind: kind}
+	case reflect.Bool:
+		// presence of the flag, e.g. "?verbose" or "?verbose="
+		if conf.flag && value == "" {
+			rv.SetBool(true)
+			break
+		}
+
+		v, err := d.parseBool(value)
+		if err != nil {
+			return err
+		}
+
+		rv.SetBool(v)
+	case reflect.String:
+		rv.SetString(value)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		v, err := strconv.ParseUint(value, conf.intBase(), bitSize())
+		if err != nil {
+			return numError(err, value, rv.Type())
+		}
+
+		rv.SetUint(v)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		v, err := strconv.ParseInt(value, conf.intBase(), bitSize())
+		if err != nil {
+			return numError(err, value, rv.Type())
+		}
+
+		rv.SetInt(v)
+	case reflect.Float32, reflect.Float64:
+		v, err := strconv.ParseFloat(value, bitSize())
+		if err != nil {
+			return numError(err, value, rv.Type())
+		}
+
+		rv.SetFloat(v)
+	case reflect.Complex64, reflect.Complex128:
+		v, err := strconv.ParseComplex(value, bitSize())
+		if err != nil {
+			return numError(err, value, rv.Type())
+		}
+
+		rv.SetComplex(v)
+	case reflect.Array:
+		if len(values) > rv.Len() {
+			return ArrayLenError{Name: conf.name, Len: rv.Len(), Got: len(values)}
+		}
+
+		// zero the elements without values
+		rv.SetZero()
+
+		for i, value := range values {
+			if err := d.setValue(rv.Index(i), []string{value}, conf); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		t := rv.Type()
+
+		if t.Elem().Kind() == reflect.Uint8 {
+			rv.SetBytes([]byte(value))
+			break
+		}
+
+		slice := reflect.MakeSlice(t, 0, len(values))
+
+		if len(values) > 0 {
+			for _, value := range values {
+				v := reflect.New(t.Elem()).Elem()
+				if err := d.setValue(v, []string{value}, conf); err != nil {
+					return err
 				}
 
-				_, ok := sft.Tag.Lookup("body")
+				slice = reflect.Append(slice, v)
+			}
+		}
+
+		rv.Set(slice)
+	}
+
+	return nil
+}
+
+// setBase64JSONValue decodes base64 (URL-safe, with or without padding) encoded JSON value.
+func setBase64JSONValue(rv reflect.Value, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+
+	b, err := decodeBase64(values[0])
+	if err != nil {
+		return fmt.Errorf("decode base64: %w", err)
+	}
+
+	if err := json.Unmarshal(b, rv.Addr().Interface()); err != nil {
+		return fmt.Errorf("decode JSON: %w", err)
+	}
+
+	return nil
+}
+
+// decodeBase64 decodes URL-safe base64 encoded value with or without padding.
+func decodeBase64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "=")) //nolint:wrapcheck
+}
+
+var timeType = reflect.TypeFor[time.Time]()
+
+// timeFormats contains named time formats defined in the OpenAPI specification.
+var timeFormats = map[string]string{
+	"date":      time.DateOnly,
+	"date-time": time.RFC3339,
+}
+
+// timeFormatUnix is the named format of the Unix time in seconds, e.g. "1700000000".
+const timeFormatUnix = "unix"
+
+// setTimeValue parses the value trying each of the time layouts or named formats in order.
+// The format is a list of layouts separated by "|". Defaults to the decoder time layouts.
+func (d Decoder) setTimeValue(rv reflect.Value, value, format string) error {
+	layouts := d.timeLayouts
+
+	if format != "" {
+		layouts = strings.Split(format, "|")
+	}
+
+	var errs []error
+
+	for _, layout := range layouts {
+		t, err := parseTime(layout, value)
+		if err == nil {
+			rv.Set(reflect.ValueOf(t))
+			return nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func parseTime(layout, value string) (time.Time, error) {
+	if layout == timeFormatUnix {
+		sec, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf(`parsing time "%s" as unix: %w`, value, err)
+		}
+
+		return time.Unix(sec, 0).UTC(), nil
+	}
+
+	if named, ok := timeFormats[layout]; ok {
+		layout = named
+	}
+
+	return time.Parse(layout, value) //nolint:wrapcheck
+}
+
+// setPositionalValue sets struct fields from values in the order of the fields.
+func (d Decoder) setPositionalValue(rv reflect.Value, values []string, conf fieldConf) error {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+
+		rv = rv.Elem()
+	}
+
+	if rv.Kind() != reflect.Struct {
+		return errors.New("expected struct for positional values")
+	}
+
+	fields := make([]reflect.Value, 0, rv.NumField())
+
+	for i := range rv.NumField() {
+		if rv.Type().Field(i).IsExported() {
+			fields = append(fields, rv.Field(i))
+		}
+	}
+
+	if len(values) != len(fields) {
+		return fmt.Errorf("want %d positional values, got %d", len(fields), len(values))
+	}
+
+	for i, v := range fields {
+		if err := d.setValue(v, values[i:i+1], conf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkMaxLen checks that every value is at most conf.maxLen characters long.
+func checkMaxLen(conf fieldConf, values []string) error {
+	if conf.maxLen == 0 {
+		return nil
+	}
+
+	for i, v := range values {
+		if utf8.RuneCountInString(v) > conf.maxLen {
+			return fmt.Errorf("element %d '%s' is longer than %d characters", i, v, conf.maxLen)
+		}
+	}
+
+	return nil
+}
+
+// patterns caches compiled regular expressions of the field tags by the expression.
+var patterns sync.Map
+
+func compilePattern(expr string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(expr); ok {
+		return re.(*regexp.Regexp), nil //nolint:forcetypeassert
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	patterns.Store(expr, re)
+
+	return re, nil
+}
+
+// checkDefault checks that the default value of the field satisfies the enum, the pattern and the range
+// of the field, e.g. "default=zzz" is invalid with "enum=a|b".
+func (d Decoder) checkDefault(conf fieldConf, t reflect.Type) error {
+	if conf.defaultValue == nil {
+		return nil
+	}
+
+	values := defaultValues(conf, t)
 
-				return ok
-			}()
+	// the default value is not replaced by the enum fallback
+	conf.enumFallback = nil
 
-			if deepQueryOrBody {
-				fields = append(fields, field{Value: sfv, Type: sft})
-			} else {
-				fields = append(fields, flattenFields(sfv)...)
-			}
-		} else {
-			fields = append(fields, field{Value: sfv, Type: sft})
-		}
+	if _, err := checkEnum(conf, values); err != nil {
+		return err
 	}
 
-	return fields
-}
-
-type fieldConf struct {
-	name     string // query name
-	style    string // serialization style
-	exploded bool   // whether exploded values
-	required bool
-}
+	if err := checkPattern(conf, values); err != nil {
+		return err
+	}
 
-func parseFieldTag(queryConf queryConf, tag string) (fieldConf, error) {
-	tag = strings.TrimSpace(tag)
-	parts := strings.Split(tag, ",")
+	if conf.min == nil && conf.max == nil {
+		return nil
+	}
 
-	if len(parts) <= 1 {
-		return fieldConf{
-			exploded: queryConf.exploded,
-			style:    queryConf.style,
-			name:     tag,
-		}, nil
+	rv := reflect.New(t).Elem()
+	if err := d.setValue(rv, values, conf); err != nil {
+		return err
 	}
 
-	conf := fieldConf{
-		exploded: queryConf.exploded,
-		style:    queryConf.style,
-		name:     strings.TrimSpace(parts[0]),
+	return checkRange(conf, rv)
+}
+
+// checkPattern checks that every value matches conf.pattern.
+func checkPattern(conf fieldConf, values []string) error {
+	if conf.pattern == nil {
+		return nil
 	}
 
-	for _, part := range parts[1:] {
-		switch v := strings.TrimSpace(part); v {
-		default:
-			return fieldConf{}, fmt.Errorf("invalid part '%s' in field tag '%s'", part, tag)
-		case "required":
-			conf.required = true
-		case "explode":
-			conf.exploded = true
-		case "implode":
-			conf.exploded = false
-		case QueryStyleForm, QueryStylePipeDelimited, QueryStyleSpaceDelimited:
-			conf.style = v
-			// implicitly implode if style is specified
-			conf.exploded = false
-		case QueryStyleDeepObject:
-			conf.style = v
+	for _, v := range values {
+		if !conf.pattern.MatchString(v) {
+			return PatternError{Name: conf.name, Value: v, Pattern: conf.pattern.String()}
 		}
 	}
 
-	return conf, nil
+	return nil
 }
 
-func parseQueryValuesDeep(name string, query map[string][]string) map[string][]string {
-	values := map[string][]string{}
+// checkEnum checks whether all values are in the enum. The value not in the enum is replaced by
+// the enum fallback, if specified.
+func checkEnum(conf fieldConf, values []string) ([]string, error) {
+	if conf.enum == nil {
+		return values, nil
+	}
 
-	for k := range query {
-		propName, ok := strings.CutPrefix(k, name+"[")
-		if !ok {
+	// do not modify the query values
+	checked := slices.Clone(values)
+
+	for i, v := range checked {
+		if slices.Contains(conf.enum, v) {
 			continue
 		}
 
-		propName, ok = strings.CutSuffix(propName, "]")
-		if !ok {
-			continue
+		if conf.enumFallback == nil {
+			return nil, EnumError{Name: conf.name, Value: v, Allowed: conf.enum}
 		}
 
-		values[propName] = query[k]
+		checked[i] = *conf.enumFallback
 	}
 
-	return values
+	return checked, nil
 }
 
-// parseQueryValues parses query parameters as defined in field tag.
-func parseQueryValues(conf fieldConf, query map[string][]string) ([]string, bool) {
-	values, ok := query[conf.name]
-	if !ok {
-		return nil, false
+// isNumeric reports whether the type, its element type for pointers and slices, is integer or float.
+func isNumeric(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
 	}
 
-	if conf.exploded || len(values) == 0 {
-		return values, true
+	switch t.Kind() { //nolint:exhaustive
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	default:
+		return false
 	}
+}
 
-	// Query is imploded. Always read the last value when expected imploded query, but received exploded - "?v=1&v=2".
-	last := values[len(values)-1]
+// checkRange checks that the numeric value, or every element of the slice, is within conf.min and conf.max.
+func checkRange(conf fieldConf, rv reflect.Value) error {
+	if conf.min == nil && conf.max == nil {
+		return nil
+	}
 
-	delimiter := ","
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
 
-	switch conf.style {
-	case QueryStyleSpaceDelimited:
-		delimiter = " "
-	case QueryStylePipeDelimited:
-		delimiter = "|"
+		rv = rv.Elem()
 	}
 
-	return strings.Split(last, delimiter), true
-}
-
-func decodeBody(r *http.Request, fieldTag string, i interface{}) error {
-	if fieldTag == "" {
-		accept := strings.ToLower(r.Header.Get("Accept"))
+	var v float64
 
-		if strings.HasPrefix(accept, "application/json") {
-			fieldTag = "json"
-		} else if strings.HasPrefix(accept, "application/xml") {
-			fieldTag = "xml"
+	switch rv.Kind() { //nolint:exhaustive
+	default:
+		return nil
+	case reflect.Slice, reflect.Array:
+		for i := range rv.Len() {
+			if err := checkRange(conf, rv.Index(i)); err != nil {
+				return err
+			}
 		}
+
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v = float64(rv.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v = float64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		v = rv.Float()
 	}
 
-	switch fieldTag {
-	default:
-		return fmt.Errorf(`want "xml" or "json", got unsupported "%s"`, fieldTag)
-	case "json":
-		err := json.NewDecoder(r.Body).Decode(i)
-		if err != nil {
-			return fmt.Errorf("decode JSON body: %w", err)
+	if conf.min != nil && v < *conf.min || conf.max != nil && v > *conf.max {
+		rangeErr := RangeError{Name: conf.name, Value: v, Min: math.Inf(-1), Max: math.Inf(1)}
+
+		if conf.min != nil {
+			rangeErr.Min = *conf.min
 		}
 
-		return nil
-	case "xml":
-		err := xml.NewDecoder(r.Body).Decode(i)
-		if err != nil {
-			return fmt.Errorf("decode XML body: %w", err)
+		if conf.max != nil {
+			rangeErr.Max = *conf.max
 		}
 
-		return nil
+		return rangeErr
 	}
-}
 
-func decodeHeaders() error {
-	return errors.New("unmarshaling header is not implemented")
+	return nil
 }
 
-func decodeQuery(queryConf queryConf, fv reflect.Value, ft reflect.StructField, query map[string][]string) error {
-	conf, err := parseFieldTag(queryConf, ft.Tag.Get("query"))
-	if err != nil {
-		return fmt.Errorf("parse field %s tag: %w", ft.Name, err)
+// sortSlice sorts slice of integers, floats or strings in ascending order.
+func sortSlice(rv reflect.Value) error {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+
+		rv = rv.Elem()
 	}
 
-	// ignore
-	if conf.name == "-" {
-		return nil
+	if rv.Kind() != reflect.Slice {
+		return errors.New("expected slice for sorted values")
 	}
 
-	if conf.name == "" {
-		// use lowercased field name
-		conf.name = strings.ToLower(ft.Name)
+	var less func(a, b reflect.Value) bool
+
+	switch kind := rv.Type().Elem().Kind(); kind { //nolint:exhaustive
+	default:
+		return fmt.Errorf("want ordered element type for sorted values, got %s", kind)
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		less = func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		less = func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		less = func(a, b reflect.Value) bool { return a.Float() < b.Float() }
+	case reflect.String:
+		less = func(a, b reflect.Value) bool { return a.String() < b.String() }
 	}
 
-	// deep object
-	if conf.style == QueryStyleDeepObject {
-		qv := parseQueryValuesDeep(conf.name, query)
-		if conf.required && len(qv) == 0 {
-			return fmt.Errorf("query param '%s' is required", conf.name)
+	sort.SliceStable(rv.Interface(), func(i, j int) bool { return less(rv.Index(i), rv.Index(j)) })
+
+	return nil
+}
+
+// overflowError is returned when the value is out of range of the numeric type, e.g. 300 for uint8.
+// It matches [strconv.ErrRange].
+type overflowError struct {
+	value string
+	t     reflect.Type
+}
+
+func (e overflowError) Error() string {
+	return fmt.Sprintf("value %s overflows %s", e.value, e.t)
+}
+
+func (e overflowError) Unwrap() error {
+	return strconv.ErrRange
+}
+
+// numError replaces the range error of parsing the number with overflowError naming the type.
+func numError(err error, value string, t reflect.Type) error {
+	if errors.Is(err, strconv.ErrRange) {
+		return overflowError{value: value, t: t}
+	}
+
+	return err
+}
+
+// setFlagsValue combines bits of named flags with bitwise OR.
+func setFlagsValue(rv reflect.Value, flags map[string]uint, tokens []string) error {
+	var bits uint
+
+	for _, token := range tokens {
+		if token == "" {
+			continue
 		}
 
-		if err := setDeepValue(queryConf, fv, qv); err != nil {
-			return fmt.Errorf("query param '%s': %w", conf.name, err)
+		bit, ok := flags[token]
+		if !ok {
+			return fmt.Errorf("unknown flag '%s'", token)
 		}
 
-		return nil
+		bits |= bit
 	}
 
-	// normal query
-	qv, ok := parseQueryValues(conf, query)
-	if !ok {
-		if conf.required {
-			return fmt.Errorf("query param '%s' is required", conf.name)
+	switch kind := rv.Kind(); kind { //nolint:exhaustive
+	default:
+		return fmt.Errorf("want integer type for flags, got %s", kind)
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		if rv.OverflowUint(uint64(bits)) {
+			return fmt.Errorf("flags %d overflow %s", bits, rv.Type())
 		}
 
-		if len(qv) == 0 {
-			return nil
+		rv.SetUint(uint64(bits))
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		if bits > math.MaxInt64 || rv.OverflowInt(int64(bits)) {
+			return fmt.Errorf("flags %d overflow %s", bits, rv.Type())
 		}
-	}
 
-	if err := setValue(fv, qv); err != nil {
-		return fmt.Errorf("query param '%s': %w", conf.name, err)
+		rv.SetInt(int64(bits))
 	}
 
 	return nil
 }
 
-func setValue(rv reflect.Value, values []string) error {
-	if len(values) == 0 {
-		return nil
-	}
-
+func (d Decoder) setDeepValue(rv reflect.Value, values queryValues) error {
 	for rv.Kind() == reflect.Ptr {
 		if rv.IsNil() {
 			rv.Set(reflect.New(rv.Type().Elem()))
@@ -534,90 +4269,120 @@ func setValue(rv reflect.Value, values []string) error {
 		rv = rv.Elem()
 	}
 
-	const bitsPerByte = 8
-
-	bitSize := func() int { return int(rv.Type().Size()) * bitsPerByte }
-
-	value := values[0]
+	rt := rv.Type()
 
-	if e, ok := rv.Addr().Interface().(encoding.TextUnmarshaler); ok {
-		if err := e.UnmarshalText([]byte(value)); err != nil {
-			return fmt.Errorf("set values %v: %w", values, err)
-		}
+	if rv.Kind() == reflect.Map {
+		return d.setDeepMapValue(rv, values)
+	}
 
-		return nil
+	if rv.Kind() != reflect.Struct {
+		return errors.New("expected struct or map for deep style")
 	}
 
-	switch kind := rv.Kind(); kind { //nolint:exhaustive
-	default:
-		return fmt.Errorf("unknown type: %s", kind)
-	case reflect.Bool:
-		v, err := strconv.ParseBool(value)
-		if err != nil {
-			return err //nolint:wrapcheck
-		}
+	for i := range rv.NumField() {
+		sfv := rv.Field(i)
+		sft := rt.Field(i)
 
-		rv.SetBool(v)
-	case reflect.String:
-		rv.SetString(value)
-	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
-		v, err := strconv.ParseUint(value, 10, bitSize())
-		if err != nil {
-			return err //nolint:wrapcheck
+		if !sft.IsExported() {
+			continue
 		}
 
-		rv.SetUint(v)
-	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
-		v, err := strconv.ParseInt(value, 10, bitSize())
+		conf, err := d.deepPropertyConf(sft)
 		if err != nil {
-			return err //nolint:wrapcheck
+			return err
 		}
 
-		rv.SetInt(v)
-	case reflect.Float32, reflect.Float64:
-		v, err := strconv.ParseFloat(value, bitSize())
-		if err != nil {
-			return err //nolint:wrapcheck
+		if _, err := d.decodeQuery(sfv, conf, values); err != nil {
+			return err
 		}
+	}
 
-		rv.SetFloat(v)
-	case reflect.Complex64, reflect.Complex128:
-		v, err := strconv.ParseComplex(value, bitSize())
-		if err != nil {
-			return err //nolint:wrapcheck
-		}
+	return nil
+}
 
-		rv.SetComplex(v)
-	case reflect.Slice:
-		t := rv.Type()
+// deepPropertyConf returns the configuration of the deep object property.
+func (d Decoder) deepPropertyConf(sft reflect.StructField) (fieldConf, error) {
+	_, tag := d.fieldTag(sft)
 
-		if t.Elem().Kind() == reflect.Uint8 {
-			rv.SetBytes([]byte(value))
-			break
+	conf, err := d.parseFieldConf(sft, originQuery, tag)
+	if err != nil {
+		return fieldConf{}, err
+	}
+
+	// match the property by the json field tag if the name is not specified,
+	// the same struct is decoded from JSON body and deep object query params
+	if name, _, _ := strings.Cut(tag, ","); strings.TrimSpace(name) == "" {
+		if jsonName, _, _ := strings.Cut(sft.Tag.Get("json"), ","); jsonName != "" && jsonName != "-" {
+			conf.name = jsonName
 		}
+	}
 
-		slice := reflect.MakeSlice(t, 0, len(values))
+	// nested deep object, e.g. "?filter[user][role]=admin"
+	if d.isDeepObject(sft.Type) {
+		conf.style = QueryStyleDeepObject
+	}
 
-		if len(values) > 0 {
-			for _, value := range values {
-				v := reflect.New(t.Elem()).Elem()
-				if err := setValue(v, []string{value}); err != nil {
-					return err
-				}
+	return conf, nil
+}
 
-				slice = reflect.Append(slice, v)
-			}
+// isDeepObject reports whether the type (or the type it points to) is decoded from nested
+// deep object properties - a struct or a map not decoded from a single value.
+func (d Decoder) isDeepObject(t reflect.Type) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if _, ok := d.decoders[t]; ok || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return false
+	}
+
+	return t.Kind() == reflect.Struct || t.Kind() == reflect.Map
+}
+
+// setFormObjectValue sets the struct fields or the map entries from the imploded object,
+// the properties and the values alternate, e.g. "?color=R,100,G,200,B,150". The properties of unexported
+// struct fields are ignored, same as deep object properties.
+func (d Decoder) setFormObjectValue(rv reflect.Value, values []string) error {
+	if len(values)%2 != 0 {
+		return fmt.Errorf("want property and value pairs, got %d values", len(values))
+	}
+
+	props := queryValues{caseSensitive: d.caseSensitiveQuery}
+
+	for i := 0; i < len(values); i += 2 {
+		props.add(values[i], values[i+1])
+	}
+
+	return d.setDeepValue(rv, props)
+}
+
+// setDeepMapValue sets map entries from the deep object properties, e.g. "?filter[status]=open&filter[tag]=a".
+func (d Decoder) setDeepMapValue(rv reflect.Value, values queryValues) error {
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(rv.Type(), len(values.names)))
+	}
+
+	for _, k := range values.names {
+		v, _ := values.get(k)
+
+		key := reflect.New(rv.Type().Key()).Elem()
+		if err := d.setValue(key, []string{k}, fieldConf{}); err != nil {
+			return fmt.Errorf("key '%s': %w", k, err)
 		}
 
-		rv.Set(slice)
+		value := reflect.New(rv.Type().Elem()).Elem()
+		if err := d.setValue(value, v, fieldConf{}); err != nil {
+			return fmt.Errorf("key '%s': %w", k, err)
+		}
+
+		rv.SetMapIndex(key, value)
 	}
 
 	return nil
 }
 
-func setDeepValue(queryConf queryConf, rv reflect.Value, values map[string][]string) error {
-	rt := rv.Type()
-
+// setKVListValue sets map entries from key-value lists, e.g. "env:prod,team:core".
+func (d Decoder) setKVListValue(rv reflect.Value, values []string, conf fieldConf) error {
 	for rv.Kind() == reflect.Ptr {
 		if rv.IsNil() {
 			rv.Set(reflect.New(rv.Type().Elem()))
@@ -626,17 +4391,40 @@ func setDeepValue(queryConf queryConf, rv reflect.Value, values map[string][]str
 		rv = rv.Elem()
 	}
 
-	if rv.Kind() != reflect.Struct {
-		return errors.New("expected struct for deep style")
+	if rv.Kind() != reflect.Map {
+		return errors.New("expected map for key-value list")
 	}
 
-	for i := range rv.NumField() {
-		sfv := rv.Field(i)
-		sft := rt.Field(i)
+	t := rv.Type()
 
-		err := decodeQuery(queryConf, sfv, sft, values)
-		if err != nil {
-			return err
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMap(t))
+	}
+
+	entrySep, pairSep := conf.kvSeparators(d.query)
+
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+
+		for _, entry := range strings.Split(value, entrySep) {
+			k, v, ok := strings.Cut(entry, pairSep)
+			if !ok {
+				return fmt.Errorf("malformed key-value pair '%s'", entry)
+			}
+
+			key := reflect.New(t.Key()).Elem()
+			if err := d.setValue(key, []string{k}, conf); err != nil {
+				return err
+			}
+
+			elem := reflect.New(t.Elem()).Elem()
+			if err := d.setValue(elem, []string{v}, conf); err != nil {
+				return err
+			}
+
+			rv.SetMapIndex(key, elem)
 		}
 	}
 
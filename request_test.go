@@ -1,15 +1,30 @@
 package request
 
 import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 	"testing"
 	"testing/quick"
+	"time"
 )
 
 func testQuery[T comparable](t *testing.T) {
@@ -49,12 +64,47 @@ func TestDecodePointerToStruct(t *testing.T) {
 
 	var i int
 
-	want = "call of Decode passes pointer to non-struct as second argument"
+	want = "call of Decode passes pointer to int as second argument, want struct, map or slice"
 	if err := Decode(r, &i); err == nil || err.Error() != want {
 		t.Errorf(`want "%s", got "%s"`, want, err)
 	}
 }
 
+func TestDecodeMapAndSlice(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/?name=alex&age=7", nil)
+
+	var query map[string]string
+
+	if err := Decode(r, &query); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]string{"name": "alex", "age": "7"}; !maps.Equal(want, query) {
+		t.Errorf("want %v, got %v", want, query)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?id=1&id=2", nil)
+
+	var ids []int
+
+	if err := Decode(r, &ids); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int{1, 2}; !slices.Equal(want, ids) {
+		t.Errorf("want %v, got %v", want, ids)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?id=1&age=2", nil)
+
+	want := "query: want single param for []int, got 2"
+	if err := Decode(r, &ids); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
 func TestDecodeQuery(t *testing.T) {
 	t.Parallel()
 
@@ -263,288 +313,4410 @@ func TestDecodeQuerySlicePipe(t *testing.T) {
 	}
 }
 
-func TestDecodeQuerySliceEmpty(t *testing.T) {
+func TestDecodeQuerySliceSemicolon(t *testing.T) {
 	t.Parallel()
 
+	err := quick.Check(func(v []string) bool {
+		var req struct {
+			Value []string `query:"value,semicolonDelimited"`
+		}
+
+		for i := range v {
+			v[i] = strings.ReplaceAll(v[i], ";", "")
+		}
+
+		queries := make(url.Values)
+		if len(v) > 0 {
+			queries.Set("value", strings.Join(v, ";"))
+		}
+
+		r := httptest.NewRequest(http.MethodGet, "/?"+queries.Encode(), nil)
+
+		if err := Decode(r, &req); err != nil {
+			t.Log(err)
+			return false
+		}
+
+		return slices.Equal(v, req.Value)
+	}, nil)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// not encoded semicolons, the last value of exploded params
 	var req struct {
-		Fields []string
+		IDs   []int `query:"ids,semicolonDelimited"`
+		Codes []string
+		Page  int
 	}
 
-	r := httptest.NewRequest(http.MethodGet, "/?fields=", nil)
+	r := httptest.NewRequest(http.MethodGet, "/?ids=9&ids=1;2;3&codes=a;b&page=2", nil)
 
-	if err := Decode(r, &req); err != nil {
-		t.Error(err)
+	if err := NewDecoder(QueryStyle(QueryStyleSemicolonDelimited), QueryImplode()).Decode(r, &req); err != nil {
+		t.Fatal(err)
 	}
 
-	want := []string{""}
-	if !slices.Equal(want, req.Fields) {
-		t.Errorf("want %v, got %v", want, req.Fields)
+	if !slices.Equal(req.IDs, []int{1, 2, 3}) || !slices.Equal(req.Codes, []string{"a", "b"}) || req.Page != 2 {
+		t.Errorf("want [1 2 3] [a b] 2, got %v %v %d", req.IDs, req.Codes, req.Page)
+	}
+
+	// semicolons are ignored in other params
+	var other struct {
+		IDs []int `query:"ids,form"`
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?ids=1;2", nil)
+
+	if err := Decode(r, &other); err != nil || other.IDs != nil {
+		t.Errorf("want nil, got %v %v", other.IDs, err)
 	}
 }
 
-func TestDecodeQueryOptional(t *testing.T) {
+func TestDecodeQuerySliceDelimiter(t *testing.T) {
 	t.Parallel()
 
 	var req struct {
-		Field bool `query:"field"`
+		IDs   []int    `oas:"ids,query,delimiter=::,implode"`
+		Tags  []string `query:"tags,pipeDelimited,delimiter=/"`
+		Names []string `query:"names,delimiter=;,default=a;b"`
 	}
 
-	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r := httptest.NewRequest(http.MethodGet, "/?ids=1::2::3&tags=a|b/c", nil)
 
 	if err := Decode(r, &req); err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
 
-	if req.Field {
-		t.Error("want false, got true")
+	if !slices.Equal(req.IDs, []int{1, 2, 3}) ||
+		!slices.Equal(req.Tags, []string{"a|b", "c"}) ||
+		!slices.Equal(req.Names, []string{"a", "b"}) {
+		t.Errorf("want [1 2 3] [a|b c] [a b], got %v %v %v", req.IDs, req.Tags, req.Names)
+	}
+
+	var invalid struct {
+		IDs []int `query:"ids,delimiter="`
+	}
+
+	if err := Decode(r, &invalid); err == nil {
+		t.Error("want invalid field tag error, got nil")
 	}
 }
 
-func TestDecodeQueryRequired(t *testing.T) {
+func TestDecodeQuerySliceMultipleStyles(t *testing.T) {
 	t.Parallel()
 
-	var req struct {
-		Field bool `query:"field,required"`
-	}
+	for _, query := range []string{"ids=1,2,3", "ids=1|2|3", "ids=1,2|3"} {
+		var req struct {
+			IDs []int `query:"ids,form,pipeDelimited"`
+		}
 
-	queries := make(url.Values)
+		r := httptest.NewRequest(http.MethodGet, "/?"+query, nil)
 
-	r := httptest.NewRequest(http.MethodGet, "/?"+queries.Encode(), nil)
+		if err := Decode(r, &req); err != nil {
+			t.Error(err)
+		}
 
-	want := "query param 'field' is required"
-	if err := Decode(r, &req); err == nil || err.Error() != want {
-		t.Errorf(`want "%s", got "%s"`, want, err)
+		want := []int{1, 2, 3}
+		if !slices.Equal(want, req.IDs) {
+			t.Errorf("%s: want %v, got %v", query, want, req.IDs)
+		}
 	}
 }
 
-func TestDecodeQueryFieldName(t *testing.T) {
+func TestDecodeQuerySliceSorted(t *testing.T) {
 	t.Parallel()
 
-	type req struct {
-		FieldOne   string
-		FieldTwo   string `query:",required"`
-		FieldThree []string
+	var req struct {
+		IDs   []int     `query:"ids,form,sorted"`
+		Names *[]string `query:"names,sorted"`
 	}
 
-	want := req{
-		FieldOne:   "foo",
-		FieldTwo:   "bar",
-		FieldThree: []string{"bazz", "fuzz"}, // sorted
+	r := httptest.NewRequest(http.MethodGet, "/?ids=3,1,2&names=zed&names=alex&names=max", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
 	}
 
-	queries := make(url.Values)
-	queries.Set("fIeLdOnE", want.FieldOne)
-	queries.Set("fieldTwo", want.FieldTwo)
-	queries.Add("fieldthree", "fuzz")
-	queries.Add("FIELDTHREE", "bazz")
+	if want := []int{1, 2, 3}; !slices.Equal(want, req.IDs) {
+		t.Errorf("want %v, got %v", want, req.IDs)
+	}
 
-	r := httptest.NewRequest(http.MethodGet, "/?"+queries.Encode(), nil)
+	if want := []string{"alex", "max", "zed"}; req.Names == nil || !slices.Equal(want, *req.Names) {
+		t.Errorf("want %v, got %v", want, req.Names)
+	}
+}
 
-	var got req
+func TestDecodeQuerySliceEmpty(t *testing.T) {
+	t.Parallel()
 
-	if err := Decode(r, &got); err != nil {
+	var req struct {
+		Fields []string
+		Names  []*string `query:"names,form"`
+		Sorts  []Sort    `query:"sorts,form"`
+		IDs    []int     `query:"ids,form"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?fields=&names=&sorts=&ids=", nil)
+
+	if err := Decode(r, &req); err != nil {
 		t.Error(err)
 	}
 
-	if want.FieldOne != got.FieldOne {
-		t.Errorf("want %s, got %s", want.FieldOne, got.FieldOne)
+	want := []string{""}
+	if !slices.Equal(want, req.Fields) {
+		t.Errorf("want %v, got %v", want, req.Fields)
 	}
 
-	if want.FieldTwo != got.FieldTwo {
-		t.Errorf("want %s, got %s", want.FieldTwo, got.FieldTwo)
+	if len(req.Names) != 1 || req.Names[0] == nil || *req.Names[0] != "" {
+		t.Errorf("want single empty name, got %v", req.Names)
 	}
 
-	slices.Sort(got.FieldThree)
+	// UnmarshalText("") is called
+	if want := []Sort{{Asc: true}}; !slices.Equal(want, req.Sorts) {
+		t.Errorf("want %v, got %v", want, req.Sorts)
+	}
 
-	if !slices.Equal(want.FieldThree, got.FieldThree) {
-		t.Errorf("want %v, got %s", want.FieldThree, got.FieldThree)
+	if req.IDs == nil || len(req.IDs) != 0 {
+		t.Errorf("want empty ids, got %v", req.IDs)
 	}
 }
 
-func TestDecodeQueryIgnore(t *testing.T) {
+func TestDecodeQueryPointerSlice(t *testing.T) {
 	t.Parallel()
 
-	var req struct {
-		Field string `query:"-"`
+	type Req struct {
+		Tags   *[]string `oas:"tags,query"`
+		IDs    *[]int    `query:"ids,form,sorted"`
+		Filter struct {
+			Roles *[]string `query:"roles"`
+		} `query:"filter,deepObject"`
 	}
 
-	queries := make(url.Values)
-	queries.Set("field", "foobar")
+	// absent
+	var got Req
 
-	r := httptest.NewRequest(http.MethodGet, "/?"+queries.Encode(), nil)
+	if err := Decode(httptest.NewRequest(http.MethodGet, "/", nil), &got); err != nil {
+		t.Fatal(err)
+	}
 
-	if err := Decode(r, &req); err != nil {
-		t.Error(err)
+	if got.Tags != nil || got.IDs != nil || got.Filter.Roles != nil {
+		t.Errorf("want nil, got %v %v %v", got.Tags, got.IDs, got.Filter.Roles)
 	}
 
-	if req.Field != "" {
-		t.Errorf("want empty, got %s", req.Field)
+	// invalid
+	if err := Decode(httptest.NewRequest(http.MethodGet, "/?ids=1,x", nil), &got); err == nil || got.IDs != nil {
+		t.Errorf("want error and nil, got %v %v", err, got.IDs)
 	}
-}
 
-func TestDecodeQueryDeep(t *testing.T) {
-	t.Parallel()
+	// present
+	r := httptest.NewRequest(http.MethodGet, "/?tags=a&tags=b&ids=3,1&filter[roles][]=admin", nil)
 
-	type Filter struct {
-		Search string `query:"find"`
-		Gt     byte
+	if err := Decode(r, &got); err != nil {
+		t.Fatal(err)
 	}
 
-	err := quick.Check(func(v Filter) bool {
-		query := make(url.Values)
-		query.Set("filter[find]", v.Search)
-		query.Set("filter[gt]", strconv.Itoa(int(v.Gt)))
-
-		r := httptest.NewRequest(http.MethodGet, "/?"+query.Encode(), nil)
+	if got.Tags == nil || !slices.Equal([]string{"a", "b"}, *got.Tags) {
+		t.Errorf("want [a b], got %v", got.Tags)
+	}
 
-		var req struct {
-			Filter `query:",deepObject"`
-		}
+	if got.IDs == nil || !slices.Equal([]int{1, 3}, *got.IDs) {
+		t.Errorf("want [1 3], got %v", got.IDs)
+	}
 
-		if err := Decode(r, &req); err != nil {
-			t.Log(err)
-		}
+	if got.Filter.Roles == nil || !slices.Equal([]string{"admin"}, *got.Filter.Roles) {
+		t.Errorf("want [admin], got %v", got.Filter.Roles)
+	}
 
-		return v == req.Filter
-	}, nil)
+	// round trip
+	r, err := Encode(http.MethodGet, "/", got)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-}
 
-type Sort struct {
-	Name string
-	Asc  bool
-}
+	var encoded Req
 
-func (s *Sort) UnmarshalText(text []byte) error {
-	words := strings.Split(string(text), ",")
-	if len(words) > 2 {
-		return fmt.Errorf("incorrectly formatted sort: %s", text)
+	if err := Decode(r, &encoded); err != nil {
+		t.Fatal(err)
 	}
 
-	s.Name = words[0]
-	s.Asc = len(words) == 1 || strings.ToLower(words[1]) == "asc"
-
-	return nil
+	if !reflect.DeepEqual(got, encoded) {
+		t.Errorf("want %+v, got %+v", got, encoded)
+	}
 }
 
-func TestDecodeUnmarshalText(t *testing.T) {
+func TestDecodeQueryPointer(t *testing.T) {
 	t.Parallel()
 
-	var req struct {
-		Sort
+	type Req struct {
+		Name *string
+		Tags *[]string
+		IDs  *[]int    `query:"ids,form"`
+		Keys *[]string `query:"keys,form"`
 	}
 
-	r := httptest.NewRequest(http.MethodGet, "/?sort=name", nil)
+	strPtr := func(s string) *string { return &s }
+
+	for _, test := range []struct {
+		query string
+		want  Req
+	}{
+		{query: "", want: Req{}},
+		{
+			query: "?name=&tags=&ids=&keys=",
+			want:  Req{Name: strPtr(""), Tags: &[]string{""}, IDs: &[]int{}, Keys: &[]string{""}},
+		},
+		{query: "?name=alex&tags=a&ids=1,2", want: Req{Name: strPtr("alex"), Tags: &[]string{"a"}, IDs: &[]int{1, 2}}},
+	} {
+		var got Req
+
+		if err := Decode(httptest.NewRequest(http.MethodGet, "/"+test.query, nil), &got); err != nil {
+			t.Error(err)
+		}
 
-	if err := Decode(r, &req); err != nil {
-		t.Error(err)
+		if !reflect.DeepEqual(test.want, got) {
+			t.Errorf(`%s: want %+v, got %+v`, test.query, test.want, got)
+		}
 	}
 
-	if req.Sort.Name != "name" {
-		t.Errorf(`want "name", got %s`, req.Sort.Name)
+	// empty value is not a number
+	var req struct {
+		Count *int
 	}
 
-	if !req.Sort.Asc {
-		t.Error("want true, got false")
+	if err := Decode(httptest.NewRequest(http.MethodGet, "/?count=", nil), &req); err == nil || req.Count != nil {
+		t.Errorf("want error and nil, got %v %v", err, req.Count)
 	}
 }
 
-func TestDecodeJSONBody(t *testing.T) {
+func TestDecodeQueryOptional(t *testing.T) {
 	t.Parallel()
 
 	var req struct {
-		Body struct {
-			ID int
-		} `body:"json"`
+		Field bool `query:"field"`
 	}
 
-	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":9}`))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
 
 	if err := Decode(r, &req); err != nil {
 		t.Error(err)
 	}
 
-	if req.Body.ID != 9 {
-		t.Errorf("want 9, got %d", req.Body.ID)
+	if req.Field {
+		t.Error("want false, got true")
 	}
 }
 
-func TestDecodeXMLBody(t *testing.T) {
+func TestDecodeQueryRequired(t *testing.T) {
 	t.Parallel()
 
 	var req struct {
-		Body struct {
-			ID int `xml:"Id"`
-		} `body:"xml"`
+		Field bool `query:"field,required"`
 	}
 
-	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`<Body><Id>1</Id></Body>`))
+	queries := make(url.Values)
 
-	if err := Decode(r, &req); err != nil {
-		t.Error(err)
-	}
+	r := httptest.NewRequest(http.MethodGet, "/?"+queries.Encode(), nil)
 
-	if req.Body.ID != 1 {
-		t.Errorf("want 1, got %d", req.Body.ID)
+	want := "query param 'field' is required"
+	if err := Decode(r, &req); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
 	}
 }
 
-func TestDecoder_DecodePath(t *testing.T) {
+func TestDecodeErrorTypes(t *testing.T) {
 	t.Parallel()
 
-	dec := NewDecoder()
+	r := httptest.NewRequest(http.MethodGet, "/?age=x&ch=1", nil)
 
-	err := quick.Check(func(id int) bool {
-		var req struct {
-			ClientID int `path:"id"`
-		}
+	var req struct {
+		Age int `query:"age"`
+	}
 
-		// Path has no impact on the test. Set path value manually.
-		r := httptest.NewRequest(http.MethodGet, "/", nil)
-		r.SetPathValue("id", strconv.Itoa(id))
+	var decodeErr DecodeError
+	if err := Decode(r, &req); !errors.As(err, &decodeErr) || decodeErr.Origin != "query" || decodeErr.Name != "age" {
+		t.Errorf("want DecodeError for query param 'age', got %v", err)
+	}
 
-		if err := dec.Decode(r, &req); err != nil {
-			t.Log(err)
-			return false
-		}
+	var unsupported struct {
+		Ch chan int `query:"ch"`
+	}
 
-		return id == req.ClientID
-	}, nil)
-	if err != nil {
-		t.Error(err)
+	var typeErr UnsupportedTypeError
+	if err := Decode(r, &unsupported); !errors.As(err, &typeErr) || typeErr.Kind != reflect.Chan {
+		t.Errorf("want UnsupportedTypeError for chan, got %v", err)
 	}
 }
 
-func TestDecodeEmbeddedStructs(t *testing.T) {
+func TestDecoder_DecodeCollectErrorsMissing(t *testing.T) {
 	t.Parallel()
 
-	type Range struct {
-		Start int `query:"rangeStart"`
-		End   int `query:"rangeEnd"`
+	var req struct {
+		Name  string `query:"name,required"`
+		Email string `query:"email,required"`
+		Age   int    `query:"age,required"`
+		Body  struct {
+			Bio string
+		} `body:"json,required"`
 	}
 
-	err := quick.Check(func(rangeStart, rangeEnd int) bool {
-		query := make(url.Values)
-		query.Set("rangeStart", strconv.Itoa(rangeStart))
-		query.Set("rangeEnd", strconv.Itoa(rangeEnd))
-		query.Set("sort", "name")
+	r := httptest.NewRequest(http.MethodPost, "/?age=7", nil)
 
-		r := httptest.NewRequest(http.MethodGet, "/?"+query.Encode(), nil)
+	err := NewDecoder(CollectErrors()).Decode(r, &req)
 
-		var req struct {
-			Sort
-			Range
-		}
+	var errs Errors
+	if !errors.As(err, &errs) {
+		t.Fatalf("want Errors, got %v", err)
+	}
 
-		if err := Decode(r, &req); err != nil {
-			t.Log(err)
-			return false
-		}
+	want := []string{"name", "email", "body"}
+	if got := errs.Missing(); !slices.Equal(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
 
-		return rangeStart == req.Range.Start &&
-			rangeEnd == req.Range.End &&
+	if req.Age != 7 {
+		t.Errorf("want 7, got %d", req.Age)
+	}
+}
+
+func TestDecoder_DecodeCollectErrorsInvalid(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		IDs  []int `query:"ids,form"`
+		Age  int   `query:"age"`
+		Name string
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?ids=1,x&age=y&name=alex", nil)
+
+	err := NewDecoder(CollectErrors()).Decode(r, &req)
+
+	var errs Errors
+	if !errors.As(err, &errs) || len(errs) != 2 {
+		t.Fatalf("want 2 errors, got %v", err)
+	}
+
+	for i, name := range []string{"ids", "age"} {
+		var decodeErr DecodeError
+		if !errors.As(errs[i], &decodeErr) || decodeErr.Name != name {
+			t.Errorf("want DecodeError for '%s', got %v", name, errs[i])
+		}
+	}
+
+	if req.IDs != nil || req.Age != 0 || req.Name != "alex" {
+		t.Errorf("want zero values of invalid fields, got %+v", req)
+	}
+}
+
+func TestDecoder_DecodeRejectMultiForScalar(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder(RejectMultiForScalar())
+
+	var req struct {
+		ID  int   `query:"id"`
+		IDs []int `query:"ids"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?id=1&ids=1&ids=2", nil)
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?id=1&id=2", nil)
+
+	want := "query param 'id': want single value, got 2"
+	if err := dec.Decode(r, &req); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecoder_DecodeRules(t *testing.T) {
+	t.Parallel()
+
+	errRequiredGroup := errors.New("either ids or search is required")
+	errExclusive := errors.New("from and ids are mutually exclusive")
+
+	dec := NewDecoder(
+		CollectErrors(),
+		Rules(
+			func(presence map[string]bool) error {
+				if !presence["IDs"] && !presence["Search"] {
+					return errRequiredGroup
+				}
+
+				return nil
+			},
+			func(presence map[string]bool) error {
+				if presence["From"] && presence["IDs"] {
+					return errExclusive
+				}
+
+				return nil
+			},
+		),
+	)
+
+	type Req struct {
+		IDs    []int  `query:"ids,form"`
+		Search string `query:"search"`
+		From   int    `query:"from"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?search=alex&from=10", nil)
+
+	if err := dec.Decode(r, &Req{}); err != nil {
+		t.Error(err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?from=10", nil)
+
+	if err := dec.Decode(r, &Req{}); !errors.Is(err, errRequiredGroup) || errors.Is(err, errExclusive) {
+		t.Errorf("want required group error, got %v", err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?ids=1,2&from=10", nil)
+
+	if err := dec.Decode(r, &Req{}); !errors.Is(err, errExclusive) || errors.Is(err, errRequiredGroup) {
+		t.Errorf("want exclusive error, got %v", err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?from=x", nil)
+
+	var errs Errors
+
+	err := dec.Decode(r, &Req{})
+	if !errors.As(err, &errs) || len(errs) != 2 || !errors.Is(err, errRequiredGroup) {
+		t.Errorf("want field and rule errors, got %v", err)
+	}
+}
+
+func TestDecoder_DecodeRulesNested(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]bool
+
+	dec := NewDecoder(Rules(func(presence map[string]bool) error {
+		got = presence
+
+		return nil
+	}))
+
+	var req struct {
+		User struct {
+			ID int `query:"user_id"`
+		}
+		Org struct {
+			ID int `query:"org_id"`
+		}
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?user_id=1", nil)
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]bool{"User.ID": true, "Org.ID": false}; !maps.Equal(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+}
+
+func TestDecoder_DecodeOnUnknownQuery(t *testing.T) {
+	t.Parallel()
+
+	var unknown []string
+
+	dec := NewDecoder(OnUnknownQuery(func(keys []string) {
+		unknown = keys
+	}))
+
+	var req struct {
+		Name   string
+		Filter struct {
+			Role string
+		} `query:"filter,deepObject"`
+		Ignored string `query:"-"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?NAME=alex&filter[role]=admin&limt=10&ignored=1&sort=name", nil)
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if want := []string{"ignored", "limt", "sort"}; !slices.Equal(want, unknown) {
+		t.Errorf("want %v, got %v", want, unknown)
+	}
+
+	unknown = nil
+	r = httptest.NewRequest(http.MethodGet, "/?name=alex", nil)
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if unknown != nil {
+		t.Errorf("want not invoked, got %v", unknown)
+	}
+}
+
+func TestDecodeCombinedTag(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		ID     int      `oas:"id,path"`
+		Tags   []string `oas:"tags,query,form,required"`
+		Limit  int      `oas:"limit"`
+		Token  string   `oas:"X-Token,header"`
+		Filter struct {
+			Status string
+		} `oas:"filter,query,deepObject"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?tags=a,b&limit=5&filter[status]=open", nil)
+	r.SetPathValue("id", "7")
+	r.Header.Set("X-Token", "secret")
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.ID != 7 || !slices.Equal(req.Tags, []string{"a", "b"}) || req.Limit != 5 || req.Token != "secret" {
+		t.Errorf("want 7 [a b] 5 secret, got %d %v %d %s", req.ID, req.Tags, req.Limit, req.Token)
+	}
+
+	if req.Filter.Status != "open" {
+		t.Errorf(`want "open", got "%s"`, req.Filter.Status)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetPathValue("id", "7")
+
+	want := "query param 'tags' is required"
+	if err := Decode(r, &req); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+
+	// empty origin is query
+	var empty struct {
+		ID int `oas:"id,,required"`
+	}
+
+	if err := Decode(httptest.NewRequest(http.MethodGet, "/", nil), &empty); !errors.As(err, new(RequiredError)) {
+		t.Errorf("want RequiredError, got %v", err)
+	}
+}
+
+func TestDecodeCombinedTagUnknownOrigin(t *testing.T) {
+	t.Parallel()
+
+	r := httptest.NewRequest(http.MethodGet, "/?tags=a,b&x=1&id=1", nil)
+
+	for _, test := range []struct {
+		name string
+		req  any
+		want string
+	}{
+		{
+			name: "setting in origin",
+			req: &struct {
+				ID int `oas:"id,required"`
+			}{},
+			want: "parse field ID tag: unknown origin 'required'",
+		},
+		{
+			name: "style in origin",
+			req: &struct {
+				Tags []string `oas:"tags,form"`
+			}{},
+			want: "parse field Tags tag: unknown origin 'form'",
+		},
+		{
+			name: "typo",
+			req: &struct {
+				X string `oas:"x,qeury"`
+			}{},
+			want: "parse field X tag: unknown origin 'qeury'",
+		},
+		{
+			name: "struct",
+			req: &struct {
+				Filter struct {
+					Status string
+				} `oas:"filter,deepObject"`
+			}{},
+			want: "parse field Filter tag: unknown origin 'deepObject'",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := Decode(r, test.req)
+			if err == nil || !strings.HasPrefix(err.Error(), test.want) {
+				t.Errorf("want %s, got %v", test.want, err)
+			}
+		})
+	}
+}
+
+func TestDecodeOriginTags(t *testing.T) {
+	t.Parallel()
+
+	// names in the field tags differ from the field names, the origin is inferred from the field tag key
+	var req struct {
+		A int    `path:"id"`
+		B int    `query:"limit,required"`
+		C string `header:"X-Request-Id"`
+		D string `cookie:"session"`
+		E struct {
+			Name string `json:"name"`
+		} `body:"json"`
+		// the combined field tag takes priority
+		F string `query:"f" oas:"X-Trace,header"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/?limit=5&a=1&b=2&f=query", strings.NewReader(`{"name":"alex"}`))
+	r.SetPathValue("id", "7")
+	r.Header.Set("X-Request-Id", "8a2f")
+	r.Header.Set("X-Trace", "header")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.A != 7 || req.B != 5 || req.C != "8a2f" || req.D != "s1" || req.E.Name != "alex" || req.F != "header" {
+		t.Errorf("want 7 5 8a2f s1 alex header, got %d %d %s %s %s %s", req.A, req.B, req.C, req.D, req.E.Name, req.F)
+	}
+}
+
+func TestDecoder_DecodeTagName(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		ID    int `req:"id,path"`
+		Limit int `req:"limit,query,max=10" oas:"size"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?limit=5&size=20", nil)
+	r.SetPathValue("id", "7")
+
+	if err := NewDecoder(TagName("req")).Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.ID != 7 || req.Limit != 5 {
+		t.Errorf("want 7 and 5, got %d and %d", req.ID, req.Limit)
+	}
+}
+
+func TestDecoder_DecodeDisallowUnknownQuery(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder(DisallowUnknownQuery())
+
+	var req struct {
+		Name   string
+		Path   string `query:"path,allowReserved"`
+		Filter struct {
+			Role string
+		} `query:"filter,deepObject"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?NAME=alex&path=/a%2Fb&filter[role]=admin&filter[user][id]=1", nil)
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?name=alex&limt=10&sort=name&Sort=id", nil)
+
+	var unknownErr UnknownParamError
+
+	err := dec.Decode(r, &req)
+	if !errors.As(err, &unknownErr) || !slices.Equal([]string{"limt", "sort"}, unknownErr.Names) {
+		t.Fatalf("want UnknownParamError, got %v", err)
+	}
+
+	if want := "unknown query params 'limt', 'sort'"; err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecodeQueryFormObject(t *testing.T) {
+	t.Parallel()
+
+	type Color struct {
+		R, G, B int
+	}
+
+	type Req struct {
+		Color   Color          `oas:"color,query,form,implode"`
+		Size    *Color         `query:"size,pipeDelimited"`
+		Weights map[string]int `query:"weights,form"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?color=R,100,G,200,B,150&size=r|1&weights=a,1,b,2", nil)
+
+	var got Req
+
+	if err := Decode(r, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Req{
+		Color:   Color{R: 100, G: 200, B: 150},
+		Size:    &Color{R: 1},
+		Weights: map[string]int{"a": 1, "b": 2},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	// unexported fields are ignored
+	var user struct {
+		User struct {
+			Name   string
+			secret string
+		} `query:"user,form"`
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?user=secret,x,name,alex", nil)
+
+	if err := Decode(r, &user); err != nil {
+		t.Fatal(err)
+	}
+
+	if user.User.Name != "alex" || user.User.secret != "" {
+		t.Errorf("want alex without secret, got %+v", user.User)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?color=R,100,G", nil)
+
+	wantErr := "query param 'color': want property and value pairs, got 3 values"
+	if err := Decode(r, &got); err == nil || err.Error() != wantErr {
+		t.Errorf(`want "%s", got "%v"`, wantErr, err)
+	}
+
+	// round trip
+	r, err := Encode(http.MethodGet, "/", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wantQuery := "color=r%2C100%2Cg%2C200%2Cb%2C150&size=r%7C1&weights=a%2C1%2Cb%2C2"; r.URL.RawQuery != wantQuery {
+		t.Errorf("want %s, got %s", wantQuery, r.URL.RawQuery)
+	}
+
+	var encoded Req
+
+	if err := Decode(r, &encoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(want, encoded) {
+		t.Errorf("want %+v, got %+v", want, encoded)
+	}
+}
+
+func TestDecoder_DecodeBoolStrings(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder(BoolStrings([]string{"yes", "on"}, []string{"no", "off"}))
+
+	var req struct {
+		Active  bool
+		Archive bool
+		Flags   []bool `query:"flags,form"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?active=Yes&archive=off&flags=on,no,true,0", nil)
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if !req.Active || req.Archive || !slices.Equal([]bool{true, false, true, false}, req.Flags) {
+		t.Errorf("want true false [true false true false], got %v %v %v", req.Active, req.Archive, req.Flags)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?active=maybe", nil)
+
+	if err := dec.Decode(r, &req); err == nil {
+		t.Error("want error, got nil")
+	}
+
+	// not accepted by default
+	r = httptest.NewRequest(http.MethodGet, "/?active=yes", nil)
+
+	if err := Decode(r, &req); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestDecoder_DecodePathVars(t *testing.T) {
+	t.Parallel()
+
+	type varsKey struct{}
+
+	// vars is similar to gorilla/mux, the router stores path params in the request context
+	vars := func(r *http.Request) map[string]string {
+		v, _ := r.Context().Value(varsKey{}).(map[string]string)
+		return v
+	}
+
+	var req struct {
+		ID   int    `path:"id"`
+		Slug string `path:"slug"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/users/1/alex", nil)
+	r = r.WithContext(context.WithValue(r.Context(), varsKey{}, map[string]string{"id": "1", "slug": "alex"}))
+
+	if err := NewDecoder(PathVars(vars)).Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.ID != 1 || req.Slug != "alex" {
+		t.Errorf("want 1 alex, got %d %s", req.ID, req.Slug)
+	}
+
+	// missing segment of required path param
+	var required struct {
+		Slug string `oas:"slug,path,required"`
+	}
+
+	r = r.WithContext(context.WithValue(r.Context(), varsKey{}, map[string]string{"id": "1"}))
+
+	err := NewDecoder(PathVars(vars)).Decode(r, &required)
+	if !errors.As(err, new(RequiredError)) || err.Error() != "path param 'slug' is required" {
+		t.Errorf("want RequiredError, got %v", err)
+	}
+}
+
+func TestDecodeQueryBase(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		Mask   uint16   `oas:"mask,query,base=16"`
+		ID     int      `query:"id,base=0"`
+		Bits   []uint16 `query:"bits,form,base=2"`
+		Offset int64    `query:"offset,base=0"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?mask=FF&id=0x1f&bits=101,11&offset=-0o17", nil)
+
+	var got Req
+
+	if err := Decode(r, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Req{Mask: 0xff, ID: 0x1f, Bits: []uint16{5, 3}, Offset: -0o17}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?mask=0xFF", nil)
+
+	if err := Decode(r, &got); err == nil {
+		t.Error("want error, got nil")
+	}
+
+	// round trip
+	r, err := Encode(http.MethodGet, "/", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if wantQuery := "bits=101%2C11&id=31&mask=ff&offset=-15"; r.URL.RawQuery != wantQuery {
+		t.Errorf("want %s, got %s", wantQuery, r.URL.RawQuery)
+	}
+
+	var invalid struct {
+		Mask int `query:"mask,base=1"`
+	}
+
+	if err := Decode(httptest.NewRequest(http.MethodGet, "/?mask=1", nil), &invalid); err == nil {
+		t.Error("want invalid base error, got nil")
+	}
+}
+
+func TestDecodeQueryArray(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		Point [3]float64 `oas:"point,query,form"`
+		IDs   [2]int     `query:"ids"`
+		Pair  *[2]string `query:"pair,pipeDelimited"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?point=1,2.5,3&ids=7&pair=a|b", nil)
+
+	var got Req
+
+	if err := Decode(r, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	want := Req{Point: [3]float64{1, 2.5, 3}, IDs: [2]int{7, 0}, Pair: &[2]string{"a", "b"}}
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %+v, got %+v", want, got)
+	}
+
+	// too many values
+	r = httptest.NewRequest(http.MethodGet, "/?point=1,2,3,4", nil)
+
+	var lenErr ArrayLenError
+
+	err := Decode(r, &got)
+	if !errors.As(err, &lenErr) || lenErr.Name != "point" || lenErr.Len != 3 || lenErr.Got != 4 {
+		t.Errorf("want ArrayLenError, got %v", err)
+	}
+
+	if want := "query param 'point': want at most 3 values, got 4"; err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%v"`, want, err)
+	}
+
+	// round trip
+	r, err = Encode(http.MethodGet, "/", want)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var encoded Req
+
+	if err := Decode(r, &encoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(want, encoded) {
+		t.Errorf("want %+v, got %+v", want, encoded)
+	}
+}
+
+func TestDecodeQueryFlag(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		Verbose bool  `oas:"verbose,query,flag"`
+		Debug   *bool `query:"debug,flag"`
+	}
+
+	yes, no := true, false
+
+	for query, want := range map[string]Req{
+		"":                         {},
+		"verbose&debug":            {Verbose: true, Debug: &yes},
+		"verbose=&debug=":          {Verbose: true, Debug: &yes},
+		"verbose=false&debug=0":    {Debug: &no},
+		"Verbose=true&debug=false": {Verbose: true, Debug: &no},
+	} {
+		var req Req
+
+		if err := Decode(httptest.NewRequest(http.MethodGet, "/?"+query, nil), &req); err != nil {
+			t.Errorf("%s: %s", query, err)
+		}
+
+		if !reflect.DeepEqual(want, req) {
+			t.Errorf("%s: want %+v, got %+v", query, want, req)
+		}
+	}
+
+	// strict without flag
+	var strict struct {
+		Verbose bool
+	}
+
+	if err := Decode(httptest.NewRequest(http.MethodGet, "/?verbose", nil), &strict); err == nil {
+		t.Error("want error, got nil")
+	}
+
+	var invalid struct {
+		Verbose string `query:"verbose,flag"`
+	}
+
+	if err := Decode(httptest.NewRequest(http.MethodGet, "/?verbose", nil), &invalid); err == nil {
+		t.Error("want field tag error, got nil")
+	}
+}
+
+func TestDecodeQueryOverflow(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Age   int8    `query:"age"`
+		Size  uint16  `query:"size"`
+		Ratio float32 `query:"ratio"`
+		IDs   []int32 `query:"ids"`
+	}
+
+	for query, want := range map[string]string{
+		"age=99999999999":      "query param 'age': value 99999999999 overflows int8",
+		"size=-1":              `query param 'size': strconv.ParseUint: parsing "-1": invalid syntax`,
+		"size=65536":           "query param 'size': value 65536 overflows uint16",
+		"ratio=1e39":           "query param 'ratio': value 1e39 overflows float32",
+		"ids=1&ids=3000000000": "query param 'ids': value 3000000000 overflows int32",
+	} {
+		err := Decode(httptest.NewRequest(http.MethodGet, "/?"+query, nil), &req)
+		if err == nil || err.Error() != want {
+			t.Errorf("%s: want %s, got %v", query, want, err)
+		}
+
+		if strings.Contains(want, "overflows") && !errors.Is(err, strconv.ErrRange) {
+			t.Errorf("%s: want strconv.ErrRange, got %v", query, err)
+		}
+	}
+}
+
+func TestDecodeQueryDottedName(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Status string   `oas:"filter.status,query"`
+		Tags   []string `query:"filter.tags,form"`
+		Sort   string   `query:"page.sort"`
+		// dotted name of the body is the path of the nested JSON object
+		Title string `oas:"data.title,body,json"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/?filter.status=open&Filter.Tags=a,b&page[sort]=name",
+		strings.NewReader(`{"data":{"title":"dotted"}}`))
+
+	if err := NewDecoder(DisallowUnknownQuery()).Decode(r, &req); !errors.As(err, new(UnknownParamError)) {
+		t.Errorf("want UnknownParamError for page[sort], got %v", err)
+	}
+
+	if req.Status != "open" || !slices.Equal(req.Tags, []string{"a", "b"}) || req.Sort != "" || req.Title != "dotted" {
+		t.Errorf("want open [a b] \"\" dotted, got %+v", req)
+	}
+}
+
+func TestDecodeQueryBrackets(t *testing.T) {
+	t.Parallel()
+
+	type User struct {
+		Name string
+		Age  int `query:"age,required"`
+	}
+
+	type Req struct {
+		User  User `oas:"user,query"`
+		Limit int
+	}
+
+	for _, query := range []string{"user[name]=alex&user[age]=30&limit=5", "name=alex&age=30&limit=5"} {
+		var req Req
+
+		r := httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+
+		if err := NewDecoder(DisallowUnknownQuery()).Decode(r, &req); err != nil {
+			t.Errorf("%s: %s", query, err)
+		}
+
+		if want := (Req{User: User{Name: "alex", Age: 30}, Limit: 5}); want != req {
+			t.Errorf("%s: want %+v, got %+v", query, want, req)
+		}
+	}
+
+	// required property of flattened struct
+	var req Req
+
+	r := httptest.NewRequest(http.MethodGet, "/?name=alex", nil)
+
+	var required RequiredError
+	if err := Decode(r, &req); !errors.As(err, &required) || required.Name != "age" {
+		t.Errorf("want RequiredError, got %v", err)
+	}
+
+	// encoded as deep object
+	r, err := Encode(http.MethodGet, "/", Req{User: User{Name: "alex", Age: 30}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "user%5Bage%5D=30&user%5Bname%5D=alex"; r.URL.RawQuery != want {
+		t.Errorf("want %s, got %s", want, r.URL.RawQuery)
+	}
+}
+
+func TestDecodeQueryBracketsFields(t *testing.T) {
+	t.Parallel()
+
+	type User struct {
+		Name   string
+		Age    int
+		secret string
+		Meta   *Meta `oas:",meta"`
+	}
+
+	type Req struct {
+		User  User `query:"user"`
+		Limit int
+	}
+
+	// unexported fields are ignored
+	var req Req
+
+	r := httptest.NewRequest(http.MethodGet, "/?user[secret]=x&user[name]=alex", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.User.Name != "alex" || req.User.secret != "" {
+		t.Errorf("want alex without secret, got %+v", req.User)
+	}
+
+	// the fields of the struct are reported, nested meta is set
+	req = Req{}
+
+	r = httptest.NewRequest(http.MethodGet, "/?name=alex&limit=5", nil)
+
+	report, err := DecodeWithReport(r, &req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []string{"User.Name", "Limit"}; !slices.Equal(want, report) {
+		t.Errorf("want %v, got %v", want, report)
+	}
+
+	if req.User.Meta == nil || req.User.Meta.Path != "/" {
+		t.Errorf("want meta, got %+v", req.User.Meta)
+	}
+
+	// field errors are collected
+	var errs Errors
+
+	r = httptest.NewRequest(http.MethodGet, "/?name=alex&age=x&limit=y", nil)
+
+	if err := NewDecoder(CollectErrors()).Decode(r, &Req{}); !errors.As(err, &errs) || len(errs) != 2 {
+		t.Errorf("want 2 errors, got %v", err)
+	}
+
+	// presence of the struct and its fields
+	var presence map[string]bool
+
+	dec := NewDecoder(Rules(func(p map[string]bool) error {
+		presence = p
+
+		return nil
+	}))
+
+	if err := dec.Decode(httptest.NewRequest(http.MethodGet, "/?age=30", nil), &Req{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if !presence["User"] || !presence["User.Age"] || presence["User.Name"] || presence["Limit"] {
+		t.Errorf("want User and User.Age present, got %v", presence)
+	}
+}
+
+func TestDecodeQueryBracketsPointer(t *testing.T) {
+	t.Parallel()
+
+	type User struct {
+		Name string
+	}
+
+	type Req struct {
+		User *User `query:"user"`
+	}
+
+	for _, query := range []string{"user[name]=alex", "name=alex"} {
+		var req Req
+
+		if err := Decode(httptest.NewRequest(http.MethodGet, "/?"+query, nil), &req); err != nil {
+			t.Fatalf("%s: %s", query, err)
+		}
+
+		if req.User == nil || req.User.Name != "alex" {
+			t.Errorf("%s: want alex, got %+v", query, req.User)
+		}
+	}
+
+	// absent
+	var req Req
+
+	if err := Decode(httptest.NewRequest(http.MethodGet, "/", nil), &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.User != nil {
+		t.Errorf("want nil, got %+v", req.User)
+	}
+}
+
+func TestDecodeQueryMapImplicitDeep(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Filter map[string]string
+		Limits *map[string][]int `oas:"limit,query"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?filter[a]=1&filter[b]=2&limit[users][]=10&limit[users][]=20", nil)
+
+	if err := NewDecoder(DisallowUnknownQuery()).Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]string{"a": "1", "b": "2"}; !maps.Equal(want, req.Filter) {
+		t.Errorf("want %v, got %v", want, req.Filter)
+	}
+
+	if want := map[string][]int{"users": {10, 20}}; req.Limits == nil || !reflect.DeepEqual(want, *req.Limits) {
+		t.Errorf("want %v, got %v", want, req.Limits)
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		ID     int        `query:"id,required"`
+		Status string     `query:"status,enum=open|closed"`
+		Body   *struct{}  `body:"json"`
+		Chan   chan<- int `header:"X-Chan"`
+	}
+
+	decoder := NewDecoder(MaxBodyBytes(1), AllowContentTypes("application/json"))
+
+	for _, test := range []struct {
+		name        string
+		target      string
+		body        string
+		contentType string
+		header      string
+		want        int
+	}{
+		{name: "ok", target: "/?id=1", want: http.StatusOK},
+		{name: "required", target: "/", want: http.StatusBadRequest},
+		{name: "invalid", target: "/?id=x", want: http.StatusBadRequest},
+		{name: "enum", target: "/?id=1&status=x", want: http.StatusBadRequest},
+		{name: "too large", target: "/?id=1", body: "{}", want: http.StatusRequestEntityTooLarge},
+		{name: "media type", target: "/?id=1", body: "{}", contentType: "text/xml", want: http.StatusUnsupportedMediaType},
+		{name: "unsupported type", target: "/?id=1", header: "1", want: http.StatusInternalServerError},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodPost, test.target, strings.NewReader(test.body))
+
+			r.Header.Set("Content-Type", "application/json")
+
+			if test.contentType != "" {
+				r.Header.Set("Content-Type", test.contentType)
+			}
+
+			if test.header != "" {
+				r.Header.Set("X-Chan", test.header)
+			}
+
+			target := req
+
+			if got := StatusCode(decoder.Decode(r, &target)); got != test.want {
+				t.Errorf("want %d, got %d", test.want, got)
+			}
+		})
+	}
+
+	if got := StatusCode(Errors{errors.New("invalid"), ErrBodyTooLarge}); got != http.StatusRequestEntityTooLarge {
+		t.Errorf("want %d, got %d", http.StatusRequestEntityTooLarge, got)
+	}
+}
+
+type period struct {
+	Start int `query:"start"`
+	End   int `query:"end"`
+}
+
+var errInvalidPeriod = errors.New("start is after end")
+
+func (p *period) Validate() error {
+	if p.Start > p.End {
+		return errInvalidPeriod
+	}
+
+	return nil
+}
+
+func TestDecodeValidator(t *testing.T) {
+	t.Parallel()
+
+	var req period
+
+	r := httptest.NewRequest(http.MethodGet, "/?start=1&end=2", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?start=3&end=2", nil)
+
+	var validationErr ValidationError
+
+	err := Decode(r, &req)
+	if !errors.As(err, &validationErr) || !errors.Is(err, errInvalidPeriod) {
+		t.Errorf("want ValidationError, got %v", err)
+	}
+
+	if _, err := DecodeTo[*period](r); !errors.Is(err, errInvalidPeriod) {
+		t.Errorf("want ValidationError, got %v", err)
+	}
+
+	var other struct {
+		Limit int
+	}
+
+	if err := DecodeMulti(r, &other, &req); !errors.Is(err, errInvalidPeriod) {
+		t.Errorf("want ValidationError, got %v", err)
+	}
+
+	// not validated if decoding fails
+	r = httptest.NewRequest(http.MethodGet, "/?start=3&end=x", nil)
+
+	if err := Decode(r, &req); !errors.As(err, new(DecodeError)) || errors.As(err, &validationErr) {
+		t.Errorf("want DecodeError, got %v", err)
+	}
+}
+
+func TestDecodeTo(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		ID   int      `query:"id"`
+		Tags []string `query:"tags"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?id=1&tags=a&tags=b", nil)
+
+	req, err := DecodeTo[Req](r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (Req{ID: 1, Tags: []string{"a", "b"}}); !reflect.DeepEqual(want, req) {
+		t.Errorf("want %+v, got %+v", want, req)
+	}
+
+	// pointer
+	ptr, err := DecodeWith[*Req](NewDecoder(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ptr == nil || ptr.ID != 1 {
+		t.Errorf("want &{ID:1}, got %+v", ptr)
+	}
+
+	// map
+	m, err := DecodeTo[map[string]string](r)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m["id"] != "1" {
+		t.Errorf("want id=1, got %v", m)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?id=x", nil)
+
+	var decodeErr DecodeError
+	if _, err := DecodeTo[Req](r); !errors.As(err, &decodeErr) {
+		t.Errorf("want DecodeError, got %v", err)
+	}
+}
+
+func TestDecodeWithReport(t *testing.T) {
+	t.Parallel()
+
+	type Paging struct {
+		Limit  int `query:"limit,default=20"`
+		Offset int `query:"offset"`
+	}
+
+	var req struct {
+		ID      int    `path:"id"`
+		Name    string `query:"name"`
+		Missing string `query:"missing"`
+		Paging
+		Options struct {
+			Verbose bool `query:"verbose"`
+		}
+		RequestID string `header:"X-Request-Id"`
+		Session   string `cookie:"session"`
+		Body      struct {
+			Email string `json:"email"`
+		} `body:"json"`
+	}
+
+	var present []string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("PATCH /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		if present, err = DecodeWithReport(r, &req); err != nil {
+			t.Error(err)
+		}
+	})
+
+	r := httptest.NewRequest(http.MethodPatch, "/users/1?name=alex&offset=10&verbose=true",
+		strings.NewReader(`{"email":"a@b.c"}`))
+	r.Header.Set("X-Request-Id", "8a2f")
+	r.AddCookie(&http.Cookie{Name: "session", Value: "s1"})
+
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := []string{"ID", "Name", "Offset", "Options.Verbose", "RequestID", "Session", "Body"}
+	if !slices.Equal(want, present) {
+		t.Errorf("want %v, got %v", want, present)
+	}
+}
+
+func TestDecodeQueryFieldName(t *testing.T) {
+	t.Parallel()
+
+	type req struct {
+		FieldOne   string
+		FieldTwo   string `query:",required"`
+		FieldThree []string
+	}
+
+	want := req{
+		FieldOne:   "foo",
+		FieldTwo:   "bar",
+		FieldThree: []string{"bazz", "fuzz"}, // in order of the query string
+	}
+
+	queries := make(url.Values)
+	queries.Set("fIeLdOnE", want.FieldOne)
+	queries.Set("fieldTwo", want.FieldTwo)
+	queries.Add("fieldthree", "fuzz")
+	queries.Add("FIELDTHREE", "bazz")
+
+	r := httptest.NewRequest(http.MethodGet, "/?"+queries.Encode(), nil)
+
+	var got req
+
+	if err := Decode(r, &got); err != nil {
+		t.Error(err)
+	}
+
+	if want.FieldOne != got.FieldOne {
+		t.Errorf("want %s, got %s", want.FieldOne, got.FieldOne)
+	}
+
+	if want.FieldTwo != got.FieldTwo {
+		t.Errorf("want %s, got %s", want.FieldTwo, got.FieldTwo)
+	}
+
+	if !slices.Equal(want.FieldThree, got.FieldThree) {
+		t.Errorf("want %v, got %s", want.FieldThree, got.FieldThree)
+	}
+}
+
+func TestDecodeQueryMixedCase(t *testing.T) {
+	t.Parallel()
+
+	var unknown []string
+
+	dec := NewDecoder(OnUnknownQuery(func(keys []string) {
+		unknown = keys
+	}))
+
+	var got struct {
+		Value  []int
+		Name   string              `query:"userName"`
+		Filter map[string][]string `query:"filter,deepObject"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet,
+		"/?Value=1&value=2&VALUE=3&value=4&USERNAME=alex&filter[Tag]=a&filter[tag]=b&Limt=1&limt=2", nil)
+
+	if err := dec.Decode(r, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int{1, 2, 3, 4}; !slices.Equal(want, got.Value) {
+		t.Errorf("want %v, got %v", want, got.Value)
+	}
+
+	if got.Name != "alex" {
+		t.Errorf("want alex, got %s", got.Name)
+	}
+
+	if want := map[string][]string{"Tag": {"a", "b"}}; !reflect.DeepEqual(want, got.Filter) {
+		t.Errorf("want %v, got %v", want, got.Filter)
+	}
+
+	if want := []string{"Limt"}; !slices.Equal(want, unknown) {
+		t.Errorf("want %v, got %v", want, unknown)
+	}
+}
+
+func TestDecodeQueryAllowReserved(t *testing.T) {
+	t.Parallel()
+
+	var got struct {
+		Path    string   `oas:"path,query,allowReserved"`
+		Decoded string   `query:"path,raw"`
+		List    []string `query:"list,form,allowReserved"`
+		Exp     []string `query:"exp,allowReserved"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?path=/docs/a%2Fb+c&list=a%2Cb,c&exp=x:y&exp=z%26", nil)
+
+	if err := Decode(r, &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "/docs/a%2Fb+c"; got.Path != want {
+		t.Errorf(`want "%s", got "%s"`, want, got.Path)
+	}
+
+	if want := "/docs/a/b c"; got.Decoded != want {
+		t.Errorf(`want "%s", got "%s"`, want, got.Decoded)
+	}
+
+	if want := []string{"a%2Cb", "c"}; !slices.Equal(want, got.List) {
+		t.Errorf("want %v, got %v", want, got.List)
+	}
+
+	if want := []string{"x:y", "z%26"}; !slices.Equal(want, got.Exp) {
+		t.Errorf("want %v, got %v", want, got.Exp)
+	}
+}
+
+func TestDecodeQueryBase64(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Token  []byte   `query:"token,base64"`
+		Cursor *string  `oas:"cursor,query,base64"`
+		Keys   []string `query:"keys,base64"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?token=AQL_&cursor=eyJpZCI6N30%3D&keys=YQ&keys=Yg==", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []byte{1, 2, 255}; !bytes.Equal(want, req.Token) {
+		t.Errorf("want %v, got %v", want, req.Token)
+	}
+
+	if want := `{"id":7}`; req.Cursor == nil || *req.Cursor != want {
+		t.Errorf("want %s, got %v", want, req.Cursor)
+	}
+
+	if want := []string{"a", "b"}; !slices.Equal(want, req.Keys) {
+		t.Errorf("want %v, got %v", want, req.Keys)
+	}
+
+	// invalid
+	r = httptest.NewRequest(http.MethodGet, "/?cursor=!", nil)
+
+	err := Decode(r, &req)
+	if err == nil || !strings.HasPrefix(err.Error(), "query param 'cursor': decode base64: ") {
+		t.Errorf("want base64 error, got %v", err)
+	}
+
+	// type
+	var invalid struct {
+		Limit int `query:"limit,base64"`
+	}
+
+	if err := Decode(r, &invalid); err == nil {
+		t.Error("want type error, got nil")
+	}
+}
+
+func TestDecoder_DecodeLenientArrays(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		IDs   []int    `query:"ids"`
+		Tags  []string `query:"tags,pipeDelimited,explode"`
+		Codes [2]string
+		Name  string
+	}
+
+	decoder := NewDecoder(LenientArrays())
+
+	for _, query := range []string{
+		"ids=1,2,3&tags=a|b&codes=x,y&name=a,b",
+		"ids=1&ids=2&ids=3&tags=a&tags=b&codes=x&codes=y&name=a,b",
+	} {
+		var req Req
+
+		if err := decoder.Decode(httptest.NewRequest(http.MethodGet, "/?"+query, nil), &req); err != nil {
+			t.Fatalf("%s: %s", query, err)
+		}
+
+		want := Req{IDs: []int{1, 2, 3}, Tags: []string{"a", "b"}, Codes: [2]string{"x", "y"}, Name: "a,b"}
+		if !reflect.DeepEqual(want, req) {
+			t.Errorf("%s: want %+v, got %+v", query, want, req)
+		}
+	}
+
+	// not split by default
+	var req Req
+
+	if err := Decode(httptest.NewRequest(http.MethodGet, "/?ids=1,2", nil), &req); !errors.As(err, new(DecodeError)) {
+		t.Errorf("want DecodeError, got %v", err)
+	}
+}
+
+func TestDecoder_DecodeTrimSpace(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Name   string   `query:"name,enum=alex|bob"`
+		IDs    []int    `query:"ids,form"`
+		Tags   []string `query:"tags"`
+		Flag   *bool    `query:"flag"`
+		ID     int      `path:"id"`
+		Limit  int      `header:"X-Limit"`
+		Cursor string   `query:"cursor"`
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := NewDecoder(TrimSpace()).Decode(r, &req); err != nil {
+			t.Error(err)
+		}
+	})
+
+	query := "name=%20alex%20&ids=1,%202%20,3&tags=+a+&tags=b&flag=+true&cursor=+"
+	r := httptest.NewRequest(http.MethodGet, "/%207?"+query, nil)
+	r.Header.Set("X-Limit", " 10")
+
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	if req.Name != "alex" || !slices.Equal(req.IDs, []int{1, 2, 3}) || !slices.Equal(req.Tags, []string{"a", "b"}) ||
+		req.Flag == nil || !*req.Flag || req.ID != 7 || req.Limit != 10 || req.Cursor != "" {
+		t.Errorf("want alex [1 2 3] [a b] true 7 10, got %+v", req)
+	}
+
+	// not trimmed by default
+	r = httptest.NewRequest(http.MethodGet, "/?ids=1,%202", nil)
+
+	if err := Decode(r, &req); !errors.As(err, new(DecodeError)) {
+		t.Errorf("want DecodeError, got %v", err)
+	}
+}
+
+func TestDecoder_DecodeCaseSensitiveQuery(t *testing.T) {
+	t.Parallel()
+
+	var unknown []string
+
+	dec := NewDecoder(CaseSensitiveQuery(), OnUnknownQuery(func(keys []string) {
+		unknown = keys
+	}))
+
+	var req struct {
+		Value  []int
+		Lower  string `query:"value"`
+		Filter struct {
+			Status string
+		} `query:"filter,deepObject"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?Value=1&value=2&Value=3&filter[Status]=open&filter[status]=closed&VALUE=4", nil)
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []int{1, 3}; !slices.Equal(want, req.Value) {
+		t.Errorf("want %v, got %v", want, req.Value)
+	}
+
+	if req.Lower != "2" {
+		t.Errorf(`want "2", got "%s"`, req.Lower)
+	}
+
+	if req.Filter.Status != "open" {
+		t.Errorf(`want "open", got "%s"`, req.Filter.Status)
+	}
+
+	if want := []string{"VALUE"}; !slices.Equal(want, unknown) {
+		t.Errorf("want %v, got %v", want, unknown)
+	}
+}
+
+func TestDecodeQueryIgnore(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Field string `query:"-"`
+	}
+
+	queries := make(url.Values)
+	queries.Set("field", "foobar")
+
+	r := httptest.NewRequest(http.MethodGet, "/?"+queries.Encode(), nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Field != "" {
+		t.Errorf("want empty, got %s", req.Field)
+	}
+}
+
+func TestDecodeQueryDeep(t *testing.T) {
+	t.Parallel()
+
+	type Filter struct {
+		Search string `query:"find"`
+		Gt     byte
+	}
+
+	err := quick.Check(func(v Filter) bool {
+		query := make(url.Values)
+		query.Set("filter[find]", v.Search)
+		query.Set("filter[gt]", strconv.Itoa(int(v.Gt)))
+
+		r := httptest.NewRequest(http.MethodGet, "/?"+query.Encode(), nil)
+
+		var req struct {
+			Filter `query:",deepObject"`
+		}
+
+		if err := Decode(r, &req); err != nil {
+			t.Log(err)
+		}
+
+		return v == req.Filter
+	}, nil)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDecodeQueryDeepNested(t *testing.T) {
+	t.Parallel()
+
+	type User struct {
+		Role  string
+		Email *string
+	}
+
+	var req struct {
+		Filter struct {
+			User    User
+			Manager *User
+			Owner   *User
+			IDs     []int             `query:"ids"`
+			Labels  map[string]string `query:"labels"`
+		} `query:"filter,deepObject"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet,
+		"/?filter[user][role]=admin&filter[manager][role]=owner&filter[ids][]=1&filter[ids][]=2&filter[labels][env]=prod", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Filter.User.Role != "admin" || req.Filter.User.Email != nil {
+		t.Errorf("want admin user without email, got %+v", req.Filter.User)
+	}
+
+	if req.Filter.Manager == nil || req.Filter.Manager.Role != "owner" {
+		t.Errorf("want owner manager, got %+v", req.Filter.Manager)
+	}
+
+	if req.Filter.Owner != nil {
+		t.Errorf("want no owner, got %+v", req.Filter.Owner)
+	}
+
+	if want := []int{1, 2}; !slices.Equal(want, req.Filter.IDs) {
+		t.Errorf("want %v, got %v", want, req.Filter.IDs)
+	}
+
+	if want := map[string]string{"env": "prod"}; !maps.Equal(want, req.Filter.Labels) {
+		t.Errorf("want %v, got %v", want, req.Filter.Labels)
+	}
+}
+
+func TestDecodeQueryDeepJSONTag(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Filter struct {
+			UserID  int    `json:"user_id"`
+			OwnerID int    `json:"owner_id" query:"owner"`
+			Status  string `json:",omitempty"`
+		} `query:"filter,deepObject"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?filter[user_id]=7&filter[owner]=8&filter[status]=open", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Filter.UserID != 7 || req.Filter.OwnerID != 8 || req.Filter.Status != "open" {
+		t.Errorf("want 7 8 open, got %+v", req.Filter)
+	}
+}
+
+func TestDecodeQueryDeepMap(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Filter map[string]string   `query:"filter,deepObject"`
+		Limits map[string]int      `query:"limit,deepObject"`
+		Tags   map[string][]string `query:"tags,deepObject"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?filter[Status]=open&filter[owner]=alex&limit[users]=10&tags[env][]=dev&tags[env][]=prod", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := map[string]string{"Status": "open", "owner": "alex"}; !maps.Equal(want, req.Filter) {
+		t.Errorf("want %v, got %v", want, req.Filter)
+	}
+
+	if want := map[string]int{"users": 10}; !maps.Equal(want, req.Limits) {
+		t.Errorf("want %v, got %v", want, req.Limits)
+	}
+
+	if want := []string{"dev", "prod"}; !slices.Equal(want, req.Tags["env"]) || len(req.Tags) != 1 {
+		t.Errorf("want map[env:%v], got %v", want, req.Tags)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?limit[users]=ten", nil)
+
+	want := `query param 'limit': key 'users': strconv.ParseInt: parsing "ten": invalid syntax`
+	if err := Decode(r, &req); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecodeQueryPositional(t *testing.T) {
+	t.Parallel()
+
+	type BBox struct {
+		MinLon, MinLat, MaxLon, MaxLat float64
+	}
+
+	var req struct {
+		BBox BBox `query:"bbox,positional"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?bbox=-10.5,20,10.5,40", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	want := BBox{MinLon: -10.5, MinLat: 20, MaxLon: 10.5, MaxLat: 40}
+	if want != req.BBox {
+		t.Errorf("want %+v, got %+v", want, req.BBox)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?bbox=-10.5,20,10.5", nil)
+
+	wantErr := "query param 'bbox': want 4 positional values, got 3"
+	if err := Decode(r, &req); err == nil || err.Error() != wantErr {
+		t.Errorf(`want "%s", got "%s"`, wantErr, err)
+	}
+}
+
+func TestDecodeQueryTime(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Default  time.Time   `query:"default"`
+		Date     time.Time   `query:"date,format=date"`
+		DateTime *time.Time  `query:"dateTime,format=date-time"`
+		Layout   time.Time   `query:"layout,format=02.01.2006"`
+		Dates    []time.Time `query:"dates,form,format=date"`
+	}
+
+	query := make(url.Values)
+	query.Set("default", "2024-01-31T12:30:00+02:00")
+	query.Set("date", "2024-01-31")
+	query.Set("dateTime", "2024-01-31T12:30:00Z")
+	query.Set("layout", "31.01.2024")
+	query.Set("dates", "2024-01-31,2024-02-29")
+
+	r := httptest.NewRequest(http.MethodGet, "/?"+query.Encode(), nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	date := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	dateTime := time.Date(2024, 1, 31, 12, 30, 0, 0, time.UTC)
+
+	if want := dateTime.Add(-2 * time.Hour); !want.Equal(req.Default) {
+		t.Errorf("want %s, got %s", want, req.Default)
+	}
+
+	if !date.Equal(req.Date) {
+		t.Errorf("want %s, got %s", date, req.Date)
+	}
+
+	if req.DateTime == nil || !dateTime.Equal(*req.DateTime) {
+		t.Errorf("want %s, got %v", dateTime, req.DateTime)
+	}
+
+	if !date.Equal(req.Layout) {
+		t.Errorf("want %s, got %s", date, req.Layout)
+	}
+
+	want := []time.Time{date, time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)}
+	if !slices.EqualFunc(want, req.Dates, time.Time.Equal) {
+		t.Errorf("want %v, got %v", want, req.Dates)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?date=2024-01-31T12:30:00Z", nil)
+
+	if err := Decode(r, &req); err == nil {
+		t.Error("want error, got no error")
+	}
+}
+
+func TestDecodeQueryBase64JSON(t *testing.T) {
+	t.Parallel()
+
+	type Cursor struct {
+		ID    int    `json:"id"`
+		Order string `json:"order"`
+	}
+
+	var req struct {
+		Cursor Cursor `query:"cursor,base64json"`
+	}
+
+	cursor := base64.RawURLEncoding.EncodeToString([]byte(`{"id":7,"order":"desc"}`))
+
+	r := httptest.NewRequest(http.MethodGet, "/?cursor="+cursor, nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if want := (Cursor{ID: 7, Order: "desc"}); want != req.Cursor {
+		t.Errorf("want %+v, got %+v", want, req.Cursor)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?cursor=!corrupt", nil)
+
+	want := "query param 'cursor': decode base64: illegal base64 data at input byte 0"
+	if err := Decode(r, &req); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecodeQueryEnum(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		Status   string   `query:"status,enum=open|closed"`
+		Statuses []string `query:"statuses,form,enum=open|closed|unknown,enumFallback=unknown"`
+	}
+
+	var req Req
+
+	r := httptest.NewRequest(http.MethodGet, "/?status=open&statuses=closed,archived,open", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Status != "open" {
+		t.Errorf(`want "open", got "%s"`, req.Status)
+	}
+
+	if want := []string{"closed", "unknown", "open"}; !slices.Equal(want, req.Statuses) {
+		t.Errorf("want %v, got %v", want, req.Statuses)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?status=archived", nil)
+
+	want := "query param 'status': value 'archived' is not one of [open closed]"
+	if err := Decode(r, &Req{}); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+
+	var enumErr EnumError
+	if err := Decode(r, &Req{}); !errors.As(err, &enumErr) || enumErr.Name != "status" || enumErr.Value != "archived" {
+		t.Errorf("want EnumError, got %v", err)
+	}
+}
+
+func TestDecodeQueryMaxLen(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		Tags []string `query:",form,maxLen=3"`
+	}
+
+	var req Req
+
+	r := httptest.NewRequest(http.MethodGet, "/?tags=a,abc", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if !slices.Equal(req.Tags, []string{"a", "abc"}) {
+		t.Errorf("want [a abc], got %v", req.Tags)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?tags=a,abcd", nil)
+
+	want := "query param 'tags': element 1 'abcd' is longer than 3 characters"
+	if err := Decode(r, &Req{}); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecodeQueryDefault(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		Limit  int      `query:"limit,default=20"`
+		Sort   []string `query:"sort,form,default=name|-created"`
+		Fields []string `query:"fields,spaceDelimited,default=id name"`
+		Bio    *string  `query:"bio,default=none"`
+	}
+
+	var req Req
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Limit != 20 || req.Bio == nil || *req.Bio != "none" {
+		t.Errorf("want 20 and none, got %d and %v", req.Limit, req.Bio)
+	}
+
+	if want := []string{"name", "-created"}; !slices.Equal(want, req.Sort) {
+		t.Errorf("want %v, got %v", want, req.Sort)
+	}
+
+	if want := []string{"id", "name"}; !slices.Equal(want, req.Fields) {
+		t.Errorf("want %v, got %v", want, req.Fields)
+	}
+
+	req = Req{}
+	r = httptest.NewRequest(http.MethodGet, "/?limit=5", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Limit != 5 {
+		t.Errorf("want 5, got %d", req.Limit)
+	}
+
+	var invalid struct {
+		Limit int `query:"limit,default=twenty"`
+	}
+
+	want := `query param 'limit': default value: strconv.ParseInt: parsing "twenty": invalid syntax`
+	if err := Decode(httptest.NewRequest(http.MethodGet, "/", nil), &invalid); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+
+	// default not satisfying the field tag settings
+	for _, target := range []any{
+		&struct {
+			S string `query:"s,enum=a|b,enumFallback=a,default=zzz"`
+		}{},
+		&struct {
+			S string `query:"s,default=zzz,pattern=^[a-b]$"`
+		}{},
+		&struct {
+			N int `query:"n,min=1,default=0"`
+		}{},
+		&struct {
+			N []int `query:"n,form,max=5,default=1|7"`
+		}{},
+	} {
+		err := Decode(httptest.NewRequest(http.MethodGet, "/", nil), target)
+		if err == nil || !strings.Contains(err.Error(), "tag: invalid default: ") {
+			t.Errorf("want invalid default error, got %v", err)
+		}
+	}
+}
+
+func TestDecodeQueryRange(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		Limit  int       `query:"limit,min=1,max=100"`
+		Offset *uint     `query:"offset,min=0"`
+		Ratios []float64 `query:"ratios,form,max=1"`
+	}
+
+	var req Req
+
+	r := httptest.NewRequest(http.MethodGet, "/?limit=100&offset=5&ratios=0.5,1", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Limit != 100 || *req.Offset != 5 || !slices.Equal(req.Ratios, []float64{0.5, 1}) {
+		t.Errorf("want 100 5 [0.5 1], got %d %d %v", req.Limit, *req.Offset, req.Ratios)
+	}
+
+	tests := []struct {
+		query, want string
+	}{
+		{query: "limit=0", want: "query param 'limit': value 0 is less than minimum 1"},
+		{query: "limit=101", want: "query param 'limit': value 101 is greater than maximum 100"},
+		{query: "ratios=0.5,1.5", want: "query param 'ratios': value 1.5 is greater than maximum 1"},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, "/?"+test.query, nil)
+
+		err := Decode(r, &Req{})
+		if err == nil || err.Error() != test.want {
+			t.Errorf(`want "%s", got "%s"`, test.want, err)
+		}
+
+		var rangeErr RangeError
+		if !errors.As(err, &rangeErr) {
+			t.Errorf("want RangeError, got %v", err)
+		}
+	}
+
+	var invalid struct {
+		Name string `query:"name,min=1"`
+	}
+
+	want := "parse field Name tag: want numeric type for min and max, got string"
+	if err := Decode(httptest.NewRequest(http.MethodGet, "/", nil), &invalid); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecodeRangeOrigins(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		ID      int `path:"id,min=1"`
+		N       int `oas:"N,header,min=1"`
+		Version int `cookie:"version,max=2"`
+	}
+
+	for _, test := range []struct {
+		id, header, cookie string
+		want               string
+	}{
+		{id: "0", header: "1", cookie: "1", want: "path 'id': value 0 is less than minimum 1"},
+		{id: "1", header: "0", cookie: "1", want: "header 'N': value 0 is less than minimum 1"},
+		{id: "1", header: "1", cookie: "3", want: "cookie 'version': value 3 is greater than maximum 2"},
+	} {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.SetPathValue("id", test.id)
+		r.Header.Set("N", test.header)
+		r.AddCookie(&http.Cookie{Name: "version", Value: test.cookie})
+
+		err := Decode(r, &req)
+		if err == nil || err.Error() != test.want || !errors.As(err, new(RangeError)) {
+			t.Errorf(`want "%s", got "%v"`, test.want, err)
+		}
+	}
+}
+
+func TestDecodeQueryRaw(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		IDs    []int  `query:"ids,form"`
+		RawIDs string `query:"ids,raw"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?ids=1,2,3", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if !slices.Equal(req.IDs, []int{1, 2, 3}) || req.RawIDs != "1,2,3" {
+		t.Errorf(`want [1 2 3] and "1,2,3", got %v and "%s"`, req.IDs, req.RawIDs)
+	}
+}
+
+func TestDecodeQueryPattern(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		Slug string   `query:"slug,pattern=^[a-z0-9-]+$"`
+		Code []string `query:"code,form,pattern=^[A-Z]{2,3}$"`
+	}
+
+	var req Req
+
+	r := httptest.NewRequest(http.MethodGet, "/?slug=go-request&code=LV,EST", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Slug != "go-request" || !slices.Equal(req.Code, []string{"LV", "EST"}) {
+		t.Errorf("want go-request [LV EST], got %s %v", req.Slug, req.Code)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?code=LV,estonia", nil)
+
+	want := "query param 'code': value 'estonia' does not match pattern '^[A-Z]{2,3}$'"
+	if err := Decode(r, &Req{}); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+
+	var patternErr PatternError
+	if err := Decode(r, &Req{}); !errors.As(err, &patternErr) || patternErr.Name != "code" || patternErr.Value != "estonia" {
+		t.Errorf("want PatternError, got %v", err)
+	}
+
+	var invalid struct {
+		Code string `query:"code,pattern=[A-Z"`
+	}
+
+	want = "parse field Code tag: invalid pattern in field tag 'code,pattern=[A-Z': " +
+		"error parsing regexp: missing closing ]: `[A-Z`"
+	if err := Decode(r, &invalid); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+
+	// setting after pattern
+	var last struct {
+		S string `query:"s,pattern=^a$,required"`
+	}
+
+	want = "parse field S tag: invalid field tag 's,pattern=^a$,required': " +
+		"setting 'required' after pattern, pattern must be last"
+	if err := Decode(httptest.NewRequest(http.MethodGet, "/", nil), &last); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%v"`, want, err)
+	}
+}
+
+func TestDecoder_DecodeQueryTimeLayouts(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Dates []time.Time `query:"dates,form"`
+		Date  time.Time   `query:"date,format=unix|date"`
+	}
+
+	dec := NewDecoder(TimeLayouts("date", "unix"))
+
+	r := httptest.NewRequest(http.MethodGet, "/?dates=2024-01-01,1700000000&date=2024-01-31", nil)
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	want := []time.Time{
+		time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		time.Unix(1700000000, 0),
+	}
+	if !slices.EqualFunc(want, req.Dates, time.Time.Equal) {
+		t.Errorf("want %v, got %v", want, req.Dates)
+	}
+
+	if want := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC); !want.Equal(req.Date) {
+		t.Errorf("want %s, got %s", want, req.Date)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?dates=2024-01-01,yesterday", nil)
+
+	if err := dec.Decode(r, &req); err == nil {
+		t.Error("want error, got no error")
+	}
+}
+
+func TestDecodeQueryNullToken(t *testing.T) {
+	t.Parallel()
+
+	bio := "bio"
+
+	req := struct {
+		Bio *string `query:"bio,nullToken=null"`
+	}{
+		Bio: &bio,
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?bio=null", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Bio != nil {
+		t.Errorf("want nil, got %s", *req.Bio)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?bio=nullable", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Bio == nil || *req.Bio != "nullable" {
+		t.Errorf(`want "nullable", got %v`, req.Bio)
+	}
+}
+
+func TestDecodeQueryKVList(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Labels map[string]string `query:"labels,kvlist"`
+		Limits map[string]int    `query:"limits,kvlist"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?labels=env:prod,team:core&limits=cpu:2&limits=mem:512", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	wantLabels := map[string]string{"env": "prod", "team": "core"}
+	if !maps.Equal(wantLabels, req.Labels) {
+		t.Errorf("want %v, got %v", wantLabels, req.Labels)
+	}
+
+	wantLimits := map[string]int{"cpu": 2, "mem": 512}
+	if !maps.Equal(wantLimits, req.Limits) {
+		t.Errorf("want %v, got %v", wantLimits, req.Limits)
+	}
+}
+
+func TestDecoder_DecodeQueryKVListSeparators(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Labels map[string]string `query:"labels,kvlist"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?labels=env=prod|team=core", nil)
+
+	if err := NewDecoder(QueryKVListSeparators("|", "=")).Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	want := map[string]string{"env": "prod", "team": "core"}
+	if !maps.Equal(want, req.Labels) {
+		t.Errorf("want %v, got %v", want, req.Labels)
+	}
+
+	// field separators, the decoder separators if not specified in the field tag
+	var fieldReq struct {
+		Labels map[string]string `query:"labels,entrySep=!"`
+		Limits map[string]string `query:"limits,kvlist,pairSep=~"`
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?labels=env=prod!team=core&limits=cpu~2|mem~512", nil)
+
+	if err := NewDecoder(QueryKVListSeparators("|", "=")).Decode(r, &fieldReq); err != nil {
+		t.Error(err)
+	}
+
+	if !maps.Equal(want, fieldReq.Labels) {
+		t.Errorf("want %v, got %v", want, fieldReq.Labels)
+	}
+
+	wantLimits := map[string]string{"cpu": "2", "mem": "512"}
+	if !maps.Equal(wantLimits, fieldReq.Limits) {
+		t.Errorf("want %v, got %v", wantLimits, fieldReq.Limits)
+	}
+}
+
+func TestDecodeQueryKVListMalformed(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Labels map[string]string `query:"labels,kvlist"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?labels=env:prod,team", nil)
+
+	want := "query param 'labels': malformed key-value pair 'team'"
+	if err := Decode(r, &req); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+type Perm uint8
+
+const (
+	PermRead Perm = 1 << iota
+	PermWrite
+	PermDelete
+)
+
+func TestDecoder_DecodeQueryFlags(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder(RegisterFlags(reflect.TypeFor[Perm](), map[string]uint{
+		"read":   uint(PermRead),
+		"write":  uint(PermWrite),
+		"delete": uint(PermDelete),
+	}))
+
+	var req struct {
+		Perms    Perm  `query:"perms,form"`
+		Exploded *Perm `query:"exploded"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?perms=read,write&exploded=read&exploded=delete", nil)
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if want := PermRead | PermWrite; req.Perms != want {
+		t.Errorf("want %b, got %b", want, req.Perms)
+	}
+
+	if want := PermRead | PermDelete; req.Exploded == nil || *req.Exploded != want {
+		t.Errorf("want %b, got %v", want, req.Exploded)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?perms=read,admin", nil)
+
+	want := "query param 'perms': unknown flag 'admin'"
+	if err := dec.Decode(r, &req); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecodeQueryDeepArray(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Filter struct {
+			Tags   []string
+			Status string
+			Labels []string
+			IDs    []int `query:"ids,form"`
+		} `query:"filter,deepObject"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet,
+		"/?filter[tags][]=a&filter[tags][]=b&filter[status]=open&filter[labels]=x&filter[labels]=y&filter[ids]=1,2", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if want := []string{"a", "b"}; !slices.Equal(want, req.Filter.Tags) {
+		t.Errorf("want %v, got %v", want, req.Filter.Tags)
+	}
+
+	if req.Filter.Status != "open" {
+		t.Errorf(`want "open", got "%s"`, req.Filter.Status)
+	}
+
+	if want := []string{"x", "y"}; !slices.Equal(want, req.Filter.Labels) {
+		t.Errorf("want %v, got %v", want, req.Filter.Labels)
+	}
+
+	if want := []int{1, 2}; !slices.Equal(want, req.Filter.IDs) {
+		t.Errorf("want %v, got %v", want, req.Filter.IDs)
+	}
+}
+
+type Sort struct {
+	Name string
+	Asc  bool
+}
+
+func (s *Sort) UnmarshalText(text []byte) error {
+	words := strings.Split(string(text), ",")
+	if len(words) > 2 {
+		return fmt.Errorf("incorrectly formatted sort: %s", text)
+	}
+
+	s.Name = words[0]
+	s.Asc = len(words) == 1 || strings.ToLower(words[1]) == "asc"
+
+	return nil
+}
+
+func TestDecodeUnmarshalText(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Sort
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?sort=name", nil)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Sort.Name != "name" {
+		t.Errorf(`want "name", got %s`, req.Sort.Name)
+	}
+
+	if !req.Sort.Asc {
+		t.Error("want true, got false")
+	}
+}
+
+func TestDecodeUnmarshalTextSlice(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Sorts   []Sort   `oas:"sort,query"`
+		Orders  []*Sort  `query:"order,pipeDelimited"`
+		Columns [2]Sort  `query:"column"`
+		Fields  *[]Sort  `query:"field"`
+		Headers []Sort   `header:"X-Sort"`
+		Ptr     *[]*Sort `query:"ptr"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet,
+		"/?sort=name&sort=created,desc&order=id|age,asc&column=a&column=b,desc&field=x&ptr=p", nil)
+	r.Header.Add("X-Sort", "name")
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []Sort{{Name: "name", Asc: true}, {Name: "created"}}; !slices.Equal(want, req.Sorts) {
+		t.Errorf("want %+v, got %+v", want, req.Sorts)
+	}
+
+	if len(req.Orders) != 2 ||
+		*req.Orders[0] != (Sort{Name: "id", Asc: true}) || *req.Orders[1] != (Sort{Name: "age", Asc: true}) {
+		t.Errorf("want [{id true} {age true}], got %+v", req.Orders)
+	}
+
+	if want := [2]Sort{{Name: "a", Asc: true}, {Name: "b"}}; want != req.Columns {
+		t.Errorf("want %+v, got %+v", want, req.Columns)
+	}
+
+	if req.Fields == nil || !slices.Equal(*req.Fields, []Sort{{Name: "x", Asc: true}}) {
+		t.Errorf("want [{x true}], got %+v", req.Fields)
+	}
+
+	if want := []Sort{{Name: "name", Asc: true}}; !slices.Equal(want, req.Headers) {
+		t.Errorf("want %+v, got %+v", want, req.Headers)
+	}
+
+	if req.Ptr == nil || len(*req.Ptr) != 1 || *(*req.Ptr)[0] != (Sort{Name: "p", Asc: true}) {
+		t.Errorf("want [{p true}], got %+v", req.Ptr)
+	}
+
+	// invalid element
+	r = httptest.NewRequest(http.MethodGet, "/?sort=name&sort=a,b,c", nil)
+
+	if err := Decode(r, &req); !errors.As(err, new(DecodeError)) {
+		t.Errorf("want DecodeError, got %v", err)
+	}
+}
+
+func TestDecodeHeader(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		RequestID string  `header:"x-request-id,required"`
+		TraceID   *string `header:"X-Trace-Id"`
+		Retries   int     `header:"X-Retries"`
+		Sort      Sort    `header:"X-Sort"`
+		Missing   *int    `header:"X-Missing"`
+	}
+
+	var req Req
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Request-Id", "8a2f")
+	r.Header.Set("X-Trace-Id", "trace")
+	r.Header.Set("X-Retries", "3")
+	r.Header.Set("X-Sort", "name,desc")
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.RequestID != "8a2f" {
+		t.Errorf(`want "8a2f", got "%s"`, req.RequestID)
+	}
+
+	if req.TraceID == nil || *req.TraceID != "trace" {
+		t.Errorf(`want "trace", got %v`, req.TraceID)
+	}
+
+	if req.Retries != 3 {
+		t.Errorf("want 3, got %d", req.Retries)
+	}
+
+	if want := (Sort{Name: "name"}); want != req.Sort {
+		t.Errorf("want %+v, got %+v", want, req.Sort)
+	}
+
+	if req.Missing != nil {
+		t.Errorf("want nil, got %d", *req.Missing)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	want := "header 'X-Request-Id' is required"
+	if err := Decode(r, &Req{}); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecodeHeaderSlice(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Encodings []string `header:"Accept-Encoding"`
+		IDs       *[]int   `header:"X-Ids"`
+		Empty     []string `header:"X-Empty"`
+		Missing   []string `header:"X-Missing"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Add("Accept-Encoding", "gzip, deflate")
+	r.Header.Add("Accept-Encoding", "br")
+	r.Header.Set("X-Ids", "1,2")
+	r.Header.Set("X-Empty", "")
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if want := []string{"gzip", "deflate", "br"}; !slices.Equal(want, req.Encodings) {
+		t.Errorf("want %v, got %v", want, req.Encodings)
+	}
+
+	if want := []int{1, 2}; req.IDs == nil || !slices.Equal(want, *req.IDs) {
+		t.Errorf("want %v, got %v", want, req.IDs)
+	}
+
+	if req.Empty == nil || len(req.Empty) != 0 {
+		t.Errorf("want empty slice, got %#v", req.Empty)
+	}
+
+	if req.Missing != nil {
+		t.Errorf("want nil, got %#v", req.Missing)
+	}
+}
+
+func TestDecodeHeaderPrefix(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Meta   map[string]string   `header:"x-meta-,prefix"`
+		Values map[string][]string `header:"X-Values-,prefix"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("X-Meta-Foo", "1")
+	r.Header.Set("x-meta-bar-baz", "2")
+	r.Header.Add("X-Values-Ids", "1")
+	r.Header.Add("X-Values-Ids", "2")
+	r.Header.Set("X-Other", "3")
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if want := map[string]string{"Foo": "1", "Bar-Baz": "2"}; !maps.Equal(want, req.Meta) {
+		t.Errorf("want %v, got %v", want, req.Meta)
+	}
+
+	if want := []string{"1", "2"}; !slices.Equal(want, req.Values["Ids"]) {
+		t.Errorf("want %v, got %v", want, req.Values["Ids"])
+	}
+}
+
+func TestDecodeCookie(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		Session string `cookie:"session,required"`
+		Visits  *int   `cookie:"visits"`
+		Sort    Sort   `cookie:"sort"`
+	}
+
+	var req Req
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "session", Value: "8a2f"})
+	r.AddCookie(&http.Cookie{Name: "visits", Value: "3"})
+	r.AddCookie(&http.Cookie{Name: "sort", Value: "name"})
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Session != "8a2f" {
+		t.Errorf(`want "8a2f", got "%s"`, req.Session)
+	}
+
+	if req.Visits == nil || *req.Visits != 3 {
+		t.Errorf("want 3, got %v", req.Visits)
+	}
+
+	if want := (Sort{Name: "name", Asc: true}); want != req.Sort {
+		t.Errorf("want %+v, got %+v", want, req.Sort)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+
+	want := "cookie 'session' is required"
+	if err := Decode(r, &Req{}); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecodeJSONBody(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Body struct {
+			ID int
+		} `body:"json"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":9}`))
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Body.ID != 9 {
+		t.Errorf("want 9, got %d", req.Body.ID)
+	}
+}
+
+func TestDecodeJSONBodyRequired(t *testing.T) {
+	t.Parallel()
+
+	type Body struct {
+		ID int `json:"id"`
+	}
+
+	// optional
+	var req struct {
+		Body *Body `body:"json"`
+	}
+
+	r := httptest.NewRequest(http.MethodPatch, "/", http.NoBody)
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Body != nil {
+		t.Errorf("want nil, got %v", req.Body)
+	}
+
+	// required
+	var required struct {
+		Body `oas:",body,json,required"`
+	}
+
+	want := "body is required"
+
+	err := Decode(r, &required)
+	if err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+
+	var requiredErr RequiredError
+	if !errors.As(err, &requiredErr) || requiredErr.Origin != "body" {
+		t.Errorf("want RequiredError, got %v", err)
+	}
+
+	r = httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(`{"id":1}`))
+
+	if err := Decode(r, &required); err != nil {
+		t.Error(err)
+	}
+
+	if required.ID != 1 {
+		t.Errorf("want 1, got %d", required.ID)
+	}
+}
+
+func TestDecodeJSONArrayBody(t *testing.T) {
+	t.Parallel()
+
+	type Item struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	var req struct {
+		Items []Item `body:"json"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`[{"id":1,"name":"one"},{"id":2,"name":"two"}]`))
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	want := []Item{{ID: 1, Name: "one"}, {ID: 2, Name: "two"}}
+	if !slices.Equal(want, req.Items) {
+		t.Errorf("want %v, got %v", want, req.Items)
+	}
+}
+
+func TestDecodeJSONBodyTypeError(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Body struct {
+			Item struct {
+				Count int `json:"count"`
+			} `json:"item"`
+		} `body:"json"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"item":{"count":5.5}}`))
+
+	err := Decode(r, &req)
+
+	want := "decode JSON body: field 'item.count': want int, got number 5.5"
+	if err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Errorf("want *json.UnmarshalTypeError, got %T", err)
+	}
+}
+
+func TestDecodeMultipartBody(t *testing.T) {
+	t.Parallel()
+
+	var body bytes.Buffer
+
+	w := multipart.NewWriter(&body)
+
+	if err := w.WriteField("title", "holiday"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.WriteField("count", "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{"avatar", "photos", "photos"} {
+		part, err := w.CreateFormFile(name, name+".png")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = part.Write([]byte(name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var req struct {
+		Form struct {
+			Title   string
+			Count   int
+			Avatar  *multipart.FileHeader   `form:"avatar"`
+			Photos  []*multipart.FileHeader `form:"photos"`
+			Missing *multipart.FileHeader
+		} `body:"multipart"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+	r.Header.Set("Content-Type", w.FormDataContentType())
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Form.Title != "holiday" || req.Form.Count != 2 {
+		t.Errorf("want holiday and 2, got %s and %d", req.Form.Title, req.Form.Count)
+	}
+
+	if req.Form.Avatar == nil || req.Form.Avatar.Filename != "avatar.png" {
+		t.Errorf("want avatar.png, got %v", req.Form.Avatar)
+	}
+
+	if len(req.Form.Photos) != 2 {
+		t.Errorf("want 2 photos, got %d", len(req.Form.Photos))
+	}
+
+	if req.Form.Missing != nil {
+		t.Errorf("want nil, got %v", req.Form.Missing)
+	}
+}
+
+func TestDecodeBodyContentType(t *testing.T) {
+	t.Parallel()
+
+	type Body struct {
+		ID int `json:"id" xml:"Id"`
+	}
+
+	tests := []struct {
+		contentType, body string
+	}{
+		{contentType: "application/xml", body: `<Body><Id>1</Id></Body>`},
+		{contentType: "application/json; charset=utf-8", body: `{"id":1}`},
+		{contentType: "", body: `{"id":1}`},
+		{contentType: "application/vnd.api+json", body: `{"id":1}`},
+		{contentType: "application/atom+xml; charset=utf-8", body: `<Body><Id>1</Id></Body>`},
+		{contentType: "application/json; charset", body: `{"id":1}`}, // invalid
+	}
+
+	for _, test := range tests {
+		var req struct {
+			Body `body:""`
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+		r.Header.Set("Content-Type", test.contentType)
+		// Accept is the response format
+		r.Header.Set("Accept", "application/json")
+
+		if err := Decode(r, &req); err != nil {
+			t.Errorf("%s: %s", test.contentType, err)
+		}
+
+		if req.ID != 1 {
+			t.Errorf("%s: want 1, got %d", test.contentType, req.ID)
+		}
+	}
+}
+
+func TestDecodeBodyRawMessage(t *testing.T) {
+	t.Parallel()
+
+	type Envelope struct {
+		Payload json.RawMessage `oas:",body,json"`
+	}
+
+	var req struct {
+		Envelope
+		Ptr     *json.RawMessage `body:""`
+		Nested  json.RawMessage  `oas:"data,body,json"`
+		TraceID string           `header:"X-Trace-Id"`
+	}
+
+	body := `{"data": [1, 2],  "id":"a"}`
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if string(req.Payload) != body {
+		t.Errorf("want %s, got %s", body, req.Payload)
+	}
+
+	if req.Ptr == nil || string(*req.Ptr) != body {
+		t.Errorf("want %s, got %v", body, req.Ptr)
+	}
+
+	if want := `[1, 2]`; string(req.Nested) != want {
+		t.Errorf("want %s, got %s", want, req.Nested)
+	}
+
+	// empty body
+	var empty struct {
+		Payload *json.RawMessage `body:"json"`
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+
+	if err := Decode(r, &empty); err != nil || empty.Payload != nil {
+		t.Errorf("want nil, got %v %v", empty.Payload, err)
+	}
+}
+
+func TestDecodeBodyPath(t *testing.T) {
+	t.Parallel()
+
+	type Attributes struct {
+		Title string `json:"title"`
+	}
+
+	var req struct {
+		Attributes *Attributes `oas:"data.attributes,body,json,required"`
+		Type       string      `oas:"data.type,body,json"`
+		Missing    *Attributes `oas:"data.relationships,body,json"`
+	}
+
+	body := `{"data":{"type":"articles","attributes":{"title":"JSON:API"}}}`
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Attributes == nil || req.Attributes.Title != "JSON:API" || req.Type != "articles" || req.Missing != nil {
+		t.Errorf("want JSON:API articles <nil>, got %+v %s %+v", req.Attributes, req.Type, req.Missing)
+	}
+
+	// required
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"data":null}`))
+
+	var required RequiredError
+	if err := Decode(r, &req); !errors.As(err, &required) {
+		t.Errorf("want RequiredError, got %v", err)
+	}
+
+	// not an object
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"data":[]}`))
+
+	err := Decode(r, &req)
+	if err == nil || !strings.HasPrefix(err.Error(), "decode JSON body: path 'data.attributes': ") {
+		t.Errorf("want path error, got %v", err)
+	}
+
+	// format
+	var xmlBody struct {
+		Attributes Attributes `oas:"data.attributes,body,xml"`
+	}
+
+	if err := Decode(r, &xmlBody); err == nil {
+		t.Error("want format error, got nil")
+	}
+}
+
+func TestDecodeBodyUnsupportedMediaType(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Body struct {
+			ID int `json:"id"`
+		} `body:""`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`<p>1</p>`))
+	r.Header.Set("Content-Type", "text/html; charset=utf-8")
+
+	var mediaTypeErr UnsupportedMediaTypeError
+
+	err := Decode(r, &req)
+	if !errors.As(err, &mediaTypeErr) || !errors.Is(err, ErrUnsupportedMediaType) {
+		t.Fatalf("want UnsupportedMediaTypeError, got %v", err)
+	}
+
+	if want := "text/html; charset=utf-8"; mediaTypeErr.MediaType != want {
+		t.Errorf(`want "%s", got "%s"`, want, mediaTypeErr.MediaType)
+	}
+
+	if want := `unsupported media type "text/html; charset=utf-8"`; err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+
+	// unknown format in the field tag
+	var invalid struct {
+		Body struct{} `body:"protobuf"`
+	}
+
+	err = Decode(r, &invalid)
+	if err == nil || err.Error() != `unsupported body format "protobuf" in field tag` {
+		t.Errorf("want unsupported body format error, got %v", err)
+	}
+
+	// the message has the format, not the name of the combined field tag
+	var combined struct {
+		Body struct{} `oas:",body,protobuf"`
+	}
+
+	err = Decode(r, &combined)
+	if err == nil || err.Error() != `unsupported body format "protobuf" in field tag` {
+		t.Errorf("want unsupported body format error, got %v", err)
+	}
+
+	// the message has the media type, not the name of the combined field tag
+	var auto struct {
+		Body struct{} `oas:",body"`
+	}
+
+	err = Decode(r, &auto)
+	if err == nil || err.Error() != `unsupported media type "text/html; charset=utf-8"` {
+		t.Errorf("want unsupported media type error, got %v", err)
+	}
+
+	// plain text is not JSON
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":1}`))
+	r.Header.Set("Content-Type", "text/plain")
+
+	if err := Decode(r, &req); !errors.Is(err, ErrUnsupportedMediaType) {
+		t.Errorf("want ErrUnsupportedMediaType, got %v", err)
+	}
+}
+
+func TestDecodeBodyEmpty(t *testing.T) {
+	t.Parallel()
+
+	type Body struct {
+		ID int `json:"id" xml:"Id"`
+	}
+
+	for _, contentType := range []string{"application/json", "application/xml"} {
+		var req struct {
+			Body Body `body:""`
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/", http.NoBody)
+		r.Header.Set("Content-Type", contentType)
+
+		if err := Decode(r, &req); err != nil {
+			t.Errorf("%s: %s", contentType, err)
+		}
+
+		var required struct {
+			Body Body `body:",required"`
+		}
+
+		want := "body is required"
+		if err := Decode(r, &required); err == nil || err.Error() != want {
+			t.Errorf(`%s: want "%s", got "%s"`, contentType, want, err)
+		}
+	}
+}
+
+func TestDecodeXMLBody(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Body struct {
+			ID int `xml:"Id"`
+		} `body:"xml"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`<Body><Id>1</Id></Body>`))
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Body.ID != 1 {
+		t.Errorf("want 1, got %d", req.Body.ID)
+	}
+}
+
+// Message mimics a generated protobuf message.
+type Message struct {
+	ID int
+}
+
+func (m *Message) Unmarshal(b []byte) error {
+	id, err := strconv.Atoi(string(b))
+	if err != nil {
+		return fmt.Errorf("unmarshal message: %w", err)
+	}
+
+	m.ID = id
+
+	return nil
+}
+
+func TestDecoder_DecodeRegisterDecoder(t *testing.T) {
+	t.Parallel()
+
+	// Money does not implement encoding.TextUnmarshaler
+	type Money struct {
+		Cents    int64
+		Currency string
+	}
+
+	dec := NewDecoder(
+		RegisterDecoder(reflect.TypeFor[Money](), func(value string) (any, error) {
+			amount, currency, _ := strings.Cut(value, " ")
+
+			cents, err := strconv.ParseInt(amount, 10, 64)
+			if err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			return Money{Cents: cents, Currency: currency}, nil
+		}),
+		// override encoding.TextUnmarshaler
+		RegisterDecoder(reflect.TypeFor[Sort](), func(value string) (any, error) {
+			return Sort{Name: strings.ToUpper(value)}, nil
+		}),
+	)
+
+	var req struct {
+		Price  Money    `query:"price"`
+		Prices []*Money `query:"prices,form"`
+		Sort   Sort     `query:"sort"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?price=100+EUR&prices=1+USD,2+USD&sort=name", nil)
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (Money{Cents: 100, Currency: "EUR"}); req.Price != want {
+		t.Errorf("want %v, got %v", want, req.Price)
+	}
+
+	if len(req.Prices) != 2 || *req.Prices[1] != (Money{Cents: 2, Currency: "USD"}) {
+		t.Errorf("want 2 prices, got %v", req.Prices)
+	}
+
+	if req.Sort.Name != "NAME" {
+		t.Errorf(`want "NAME", got "%s"`, req.Sort.Name)
+	}
+}
+
+func TestDecoder_DecodeUseNumber(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Body map[string]any `body:"json"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":9007199254740993,"ratio":0.5}`))
+
+	if err := NewDecoder(UseNumber()).Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := json.Number("9007199254740993"); req.Body["id"] != want {
+		t.Errorf("want %v, got %v (%T)", want, req.Body["id"], req.Body["id"])
+	}
+
+	if want := json.Number("0.5"); req.Body["ratio"] != want {
+		t.Errorf("want %v, got %v (%T)", want, req.Body["ratio"], req.Body["ratio"])
+	}
+
+	// float64 by default
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":1}`))
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := req.Body["id"].(float64); !ok {
+		t.Errorf("want float64, got %T", req.Body["id"])
+	}
+}
+
+func TestDecoder_DecodeDisallowUnknownBodyFields(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Body struct {
+			Name string `json:"name"`
+		} `body:"json"`
+	}
+
+	dec := NewDecoder(DisallowUnknownBodyFields())
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"alex","nmae":"alex"}`))
+
+	err := dec.Decode(r, &req)
+	if !errors.Is(err, ErrUnknownField) {
+		t.Fatalf("want ErrUnknownField, got %v", err)
+	}
+
+	if want := `decode JSON body: unknown field "nmae"`; err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+
+	// unknown fields are ignored by default
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"alex","nmae":"alex"}`))
+
+	if err := Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDecoder_DecodeDecompressBody(t *testing.T) {
+	t.Parallel()
+
+	compress := func(w io.WriteCloser, s string) {
+		if _, err := io.WriteString(w, s); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var gzipped, zlibbed, deflated bytes.Buffer
+
+	compress(gzip.NewWriter(&gzipped), `{"name":"alex"}`)
+	compress(zlib.NewWriter(&zlibbed), `{"name":"alex"}`)
+
+	fw, _ := flate.NewWriter(&deflated, flate.DefaultCompression)
+	compress(fw, `{"name":"alex"}`)
+
+	dec := NewDecoder(DecompressBody(), MaxBodyBytes(15))
+
+	for _, test := range []struct {
+		encoding string
+		body     []byte
+		wantErr  string
+	}{
+		{encoding: "gzip", body: gzipped.Bytes()},
+		{encoding: "deflate", body: zlibbed.Bytes()},
+		{encoding: "deflate", body: deflated.Bytes()},
+		{encoding: "", body: []byte(`{"name":"alex"}`)},
+		{encoding: "br", body: []byte(`{"name":"alex"}`), wantErr: "decompress body: unsupported content encoding 'br'"},
+		{encoding: "gzip", body: []byte(`{"name":"alex"}`), wantErr: "decompress body: read gzip header: gzip: invalid header"},
+	} {
+		var req struct {
+			Body struct {
+				Name string `json:"name"`
+			} `body:"json"`
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(test.body))
+		r.Header.Set("Content-Encoding", test.encoding)
+
+		err := dec.Decode(r, &req)
+
+		switch {
+		case test.wantErr != "":
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf(`%s: want "%s", got "%v"`, test.encoding, test.wantErr, err)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", test.encoding, err)
+		case req.Body.Name != "alex":
+			t.Errorf("%s: want alex, got %s", test.encoding, req.Body.Name)
+		}
+	}
+}
+
+func TestDecoder_DecodeContext(t *testing.T) {
+	t.Parallel()
+
+	type tenantKey struct{}
+
+	type Account struct {
+		Tenant string
+		ID     string
+	}
+
+	dec := NewDecoder(
+		RegisterDecoderContext(reflect.TypeFor[Account](), func(ctx context.Context, value string) (any, error) {
+			if err := ctx.Err(); err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			tenant, _ := ctx.Value(tenantKey{}).(string)
+
+			return Account{Tenant: tenant, ID: value}, nil
+		}),
+	)
+
+	var req struct {
+		Account Account `query:"account"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?account=42", nil)
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+
+	if err := dec.DecodeContext(ctx, r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (Account{Tenant: "acme", ID: "42"}); req.Account != want {
+		t.Errorf("want %v, got %v", want, req.Account)
+	}
+
+	// request context
+	if err := dec.Decode(r.WithContext(ctx), &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (Account{Tenant: "acme", ID: "42"}); req.Account != want {
+		t.Errorf("want %v, got %v", want, req.Account)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	if err := dec.DecodeContext(ctx, r, &req); !errors.Is(err, context.Canceled) {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+}
+
+type Shape interface {
+	Area() float64
+}
+
+type Circle struct {
+	Type   string  `json:"type"`
+	Radius float64 `json:"radius"`
+}
+
+func (c Circle) Area() float64 { return 3 * c.Radius * c.Radius }
+
+type Square struct {
+	Type string  `json:"type"`
+	Side float64 `json:"side"`
+}
+
+func (s *Square) Area() float64 { return s.Side * s.Side }
+
+func TestDecoder_DecodeBodyDiscriminator(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder(
+		BodyDiscriminator(reflect.TypeFor[Shape](), func(data json.RawMessage) (any, error) {
+			var v struct {
+				Type string `json:"type"`
+			}
+
+			if err := json.Unmarshal(data, &v); err != nil {
+				return nil, err //nolint:wrapcheck
+			}
+
+			switch v.Type {
+			default:
+				return nil, fmt.Errorf("unknown shape '%s'", v.Type)
+			case "circle":
+				return &Circle{}, nil
+			case "square":
+				return &Square{}, nil
+			}
+		}),
+		DisallowUnknownBodyFields(),
+	)
+
+	for _, test := range []struct {
+		body    string
+		want    Shape
+		wantErr string
+	}{
+		{body: `{"type":"circle","radius":2}`, want: &Circle{Type: "circle", Radius: 2}},
+		{body: `{"type":"square","side":3}`, want: &Square{Type: "square", Side: 3}},
+		{body: `{"type":"circle","radius":"2"}`, wantErr: "decode JSON body: field 'radius': want float64, got string"},
+		{body: `{"type":"triangle"}`, wantErr: "decode JSON body: unknown shape 'triangle'"},
+		{body: `{"type":"circle","side":3}`, wantErr: `decode JSON body: unknown field "side"`},
+	} {
+		var req struct {
+			Shape Shape `body:"json"`
+		}
+
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(test.body))
+
+		err := dec.Decode(r, &req)
+
+		switch {
+		case test.wantErr != "":
+			if err == nil || err.Error() != test.wantErr {
+				t.Errorf(`%s: want "%s", got "%v"`, test.body, test.wantErr, err)
+			}
+		case err != nil:
+			t.Errorf("%s: %v", test.body, err)
+		case !reflect.DeepEqual(test.want, req.Shape):
+			t.Errorf("%s: want %#v, got %#v", test.body, test.want, req.Shape)
+		}
+	}
+}
+
+func TestDecodeTextBody(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		Payload   []byte  `oas:",body,text"`
+		Signature string  `header:"X-Signature"`
+		Text      *string `body:"text"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"event":"push"}`))
+	r.Header.Set("X-Signature", "sha256=1f")
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := `{"event":"push"}`; string(req.Payload) != want || req.Text == nil || *req.Text != want {
+		t.Errorf("want %s, got %s %v", want, req.Payload, req.Text)
+	}
+
+	// limited body
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("too long"))
+
+	if err := NewDecoder(MaxBodyBytes(3)).Decode(r, &req); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("want ErrBodyTooLarge, got %v", err)
+	}
+
+	var invalid struct {
+		Body int `body:"text"`
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("1"))
+
+	if err := Decode(r, &invalid); err == nil || err.Error() != "decode text body: want string or []byte, got int" {
+		t.Errorf("want unsupported type error, got %v", err)
+	}
+}
+
+func TestDecodeCSVBody(t *testing.T) {
+	t.Parallel()
+
+	type Record struct {
+		ID    int `json:"id"`
+		Name  string
+		Score *float64
+	}
+
+	score := 9.5
+
+	var req struct {
+		Records []Record `body:"csv"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name,id,unknown,score\nalex,1,x,9.5\nbob,2,y,\n"))
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []Record{{ID: 1, Name: "alex", Score: &score}, {ID: 2, Name: "bob"}}
+	if !reflect.DeepEqual(want, req.Records) {
+		t.Errorf("want %+v, got %+v", want, req.Records)
+	}
+
+	// delimiter and Content-Type
+	var delimited struct {
+		Records []*Record `oas:",body,delimiter=;"`
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("ID;NAME\n1;alex\n"))
+	r.Header.Set("Content-Type", "text/csv")
+
+	if err := NewDecoder().Decode(r, &delimited); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(delimited.Records) != 1 || *delimited.Records[0] != (Record{ID: 1, Name: "alex"}) {
+		t.Errorf("want [{ID:1 Name:alex}], got %+v", delimited.Records)
+	}
+
+	// invalid value
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("id\n1\nx\n"))
+
+	err := Decode(r, &req)
+	if err == nil || !strings.HasPrefix(err.Error(), "decode CSV body: line 3, column 'id': ") {
+		t.Errorf("want CSV error, got %v", err)
+	}
+
+	// invalid delimiter
+	var invalid struct {
+		Records []Record `oas:",body,csv,delimiter=;;"`
+	}
+
+	if err := Decode(r, &invalid); err == nil {
+		t.Error("want invalid field tag error, got nil")
+	}
+
+	// not a slice of structs
+	for _, target := range []any{
+		&struct {
+			B string `body:"csv"`
+		}{},
+		&struct {
+			B []int `body:"csv"`
+		}{},
+	} {
+		r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("id\n1\n"))
+
+		if err := Decode(r, target); !errors.As(err, new(UnsupportedTypeError)) {
+			t.Errorf("want UnsupportedTypeError, got %v", err)
+		}
+	}
+}
+
+func TestDecoder_DecodeXMLOptions(t *testing.T) {
+	t.Parallel()
+
+	type Envelope struct {
+		XMLName xml.Name `xml:"urn:example Envelope"`
+		ID      string   `xml:"id,attr"`
+		Name    string   `xml:"Name"`
+	}
+
+	// latin1 converts ISO-8859-1 to UTF-8
+	latin1 := func(charset string, input io.Reader) (io.Reader, error) {
+		if !strings.EqualFold(charset, "ISO-8859-1") {
+			return nil, fmt.Errorf("unsupported charset %s", charset)
+		}
+
+		b, err := io.ReadAll(input)
+		if err != nil {
+			return nil, err //nolint:wrapcheck
+		}
+
+		runes := make([]rune, len(b))
+		for i, c := range b {
+			runes[i] = rune(c)
+		}
+
+		return strings.NewReader(string(runes)), nil
+	}
+
+	body := "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><Envelope id=7><Name>J\xfcrgen&nbsp;</Name></Envelope>"
+
+	var req struct {
+		Envelope Envelope `body:"xml"`
+	}
+
+	decoder := NewDecoder(XMLCharsetReader(latin1), XMLNonStrict(), XMLDefaultSpace("urn:example"))
+
+	if err := decoder.Decode(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Envelope.ID != "7" || req.Envelope.Name != "Jürgen\u00a0" {
+		t.Errorf(`want 7 "Jürgen\u00a0", got %s %q`, req.Envelope.ID, req.Envelope.Name)
+	}
+
+	// strict by default, no charset reader
+	if err := Decode(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), &req); err == nil {
+		t.Error("want error, got nil")
+	}
+}
+
+func TestDecoder_DecodeYAMLBody(t *testing.T) {
+	t.Parallel()
+
+	// unmarshalYAML supports flat "key: value" documents only
+	unmarshalYAML := func(data []byte, v any) error {
+		m := make(map[string]string)
+
+		for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+			key, value, ok := strings.Cut(line, ":")
+			if !ok {
+				return fmt.Errorf("invalid line '%s'", line)
+			}
+
+			m[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+
+		b, _ := json.Marshal(m)
+
+		return json.Unmarshal(b, v) //nolint:wrapcheck
+	}
+
+	type Config struct {
+		Name string `json:"name"`
+		Env  string `json:"env"`
+	}
+
+	dec := NewDecoder(YAMLUnmarshaler(unmarshalYAML))
+
+	var req struct {
+		Config `body:""`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name: api\nenv: prod\n"))
+	r.Header.Set("Content-Type", "application/x-yaml")
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := (Config{Name: "api", Env: "prod"}); req.Config != want {
+		t.Errorf("want %+v, got %+v", want, req.Config)
+	}
+
+	var explicit struct {
+		Config `body:"yaml"`
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("name api"))
+
+	want := "decode YAML body: invalid line 'name api'"
+	if err := dec.Decode(r, &explicit); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecoder_DecodeBodyDecoder(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder(BodyDecoder("protobuf", func(r io.Reader, v any) error {
+		m, ok := v.(interface{ Unmarshal(b []byte) error })
+		if !ok {
+			return fmt.Errorf("want message, got %T", v)
+		}
+
+		b, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("read body: %w", err)
+		}
+
+		return m.Unmarshal(b)
+	}, "application/x-protobuf"))
+
+	var req struct {
+		Explicit Message `body:"protobuf"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("7"))
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Explicit.ID != 7 {
+		t.Errorf("want 7, got %d", req.Explicit.ID)
+	}
+
+	var detected struct {
+		Message *Message `body:""`
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("9"))
+	r.Header.Set("Content-Type", "application/x-protobuf")
+
+	if err := dec.Decode(r, &detected); err != nil {
+		t.Error(err)
+	}
+
+	if detected.Message == nil || detected.Message.ID != 9 {
+		t.Errorf("want 9, got %v", detected.Message)
+	}
+
+	var invalid struct {
+		Message int `body:"protobuf"`
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("9"))
+
+	want := "decode protobuf body: want message, got *int"
+	if err := dec.Decode(r, &invalid); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecoder_DecodeAllowContentTypes(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder(AllowContentTypes("application/json"))
+
+	var req struct {
+		Body struct {
+			ID int
+		} `body:"json"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":1}`))
+	r.Header.Set("Content-Type", "Application/JSON; charset=utf-8")
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Body.ID != 1 {
+		t.Errorf("want 1, got %d", req.Body.ID)
+	}
+
+	for _, contentType := range []string{"application/xml", "", "invalid;;"} {
+		r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":1}`))
+		r.Header.Set("Content-Type", contentType)
+
+		if err := dec.Decode(r, &req); !errors.Is(err, ErrUnsupportedMediaType) {
+			t.Errorf("%s: want ErrUnsupportedMediaType, got %v", contentType, err)
+		}
+	}
+
+	// no body field
+	var query struct {
+		ID int
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/?id=1", nil)
+	r.Header.Set("Content-Type", "application/xml")
+
+	if err := dec.Decode(r, &query); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDecodeMulti(t *testing.T) {
+	t.Parallel()
+
+	var (
+		query struct {
+			Age int `query:"age"`
+		}
+		body struct {
+			Body struct {
+				Name string
+			} `body:"json"`
+		}
+		raw struct {
+			Body json.RawMessage `body:"json"`
+		}
+	)
+
+	r := httptest.NewRequest(http.MethodPost, "/?age=7", strings.NewReader(`{"name":"alex"}`))
+
+	if err := DecodeMulti(r, &query, &body, &raw); err != nil {
+		t.Error(err)
+	}
+
+	if query.Age != 7 {
+		t.Errorf("want 7, got %d", query.Age)
+	}
+
+	if body.Body.Name != "alex" {
+		t.Errorf(`want "alex", got "%s"`, body.Body.Name)
+	}
+
+	if want := `{"name":"alex"}`; string(raw.Body) != want {
+		t.Errorf("want %s, got %s", want, raw.Body)
+	}
+
+	want := "call of DecodeMulti passes non-pointer as target 1"
+	if err := DecodeMulti(r, &query, body); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecodeGeneric(t *testing.T) {
+	t.Parallel()
+
+	var got map[string]any
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /users/{id}/{rest...}", func(w http.ResponseWriter, r *http.Request) {
+		var err error
+
+		if got, err = DecodeGeneric(r); err != nil {
+			t.Error(err)
+		}
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/users/1/a/b?expand=roles&expand=groups", strings.NewReader(`{"name":"alex","age":7}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := map[string]any{
+		"query":  map[string][]string{"expand": {"roles", "groups"}},
+		"path":   map[string]string{"id": "1", "rest": "a/b"},
+		"header": map[string][]string{"Content-Type": {"application/json"}},
+		"body":   map[string]any{"name": "alex", "age": float64(7)},
+	}
+
+	if !reflect.DeepEqual(want, got) {
+		t.Errorf("want %v, got %v", want, got)
+	}
+
+	r = httptest.NewRequest(http.MethodGet, "/", strings.NewReader("plain"))
+
+	got, err := DecodeGeneric(r)
+	if err != nil {
+		t.Error(err)
+	}
+
+	if _, ok := got["path"]; ok {
+		t.Errorf("want no path, got %v", got["path"])
+	}
+
+	if got["body"] != "plain" {
+		t.Errorf(`want "plain", got %v`, got["body"])
+	}
+
+	// body size limit
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(strings.Repeat("a", 1000)))
+
+	if _, err := NewDecoder(MaxBodyBytes(10)).DecodeGeneric(r); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("want ErrBodyTooLarge, got %v", err)
+	}
+
+	// compressed body
+	var gzipped bytes.Buffer
+
+	zw := gzip.NewWriter(&gzipped)
+	if _, err := zw.Write([]byte("plain")); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", &gzipped)
+	r.Header.Set("Content-Encoding", "gzip")
+
+	if got, err = NewDecoder(DecompressBody()).DecodeGeneric(r); err != nil {
+		t.Fatal(err)
+	}
+
+	if got["body"] != "plain" {
+		t.Errorf(`want "plain", got %v`, got["body"])
+	}
+}
+
+func TestDecodeStream(t *testing.T) {
+	t.Parallel()
+
+	const n = 10000
+
+	var body bytes.Buffer
+
+	body.WriteString("[")
+
+	for i := range n {
+		if i > 0 {
+			body.WriteString(",")
+		}
+
+		fmt.Fprintf(&body, `{"id":%d}`, i)
+	}
+
+	body.WriteString("]")
+
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+
+	var count, sum int
+
+	err := DecodeStream(r, "json", func(elem json.RawMessage) error {
+		var item struct {
+			ID int `json:"id"`
+		}
+
+		if err := json.Unmarshal(elem, &item); err != nil {
+			return err //nolint:wrapcheck
+		}
+
+		count++
+		sum += item.ID
+
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if count != n || sum != n*(n-1)/2 {
+		t.Errorf("want %d elements, got %d", n, count)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":1}`))
+
+	want := "decode JSON body: want array, got {"
+	if err := DecodeStream(r, "json", func(json.RawMessage) error { return nil }); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecoder_DecodeMaxBodyBytes(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder(MaxBodyBytes(12))
+
+	var req struct {
+		Body struct {
+			Name string
+		} `body:"json"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"a"}`))
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"name":"alex"}`))
+
+	if err := dec.Decode(r, &req); !errors.Is(err, ErrBodyTooLarge) {
+		t.Errorf("want ErrBodyTooLarge, got %v", err)
+	}
+}
+
+func TestDecoder_DecodeCSRF(t *testing.T) {
+	t.Parallel()
+
+	errMismatch := errors.New("token mismatch")
+
+	dec := NewDecoder(CSRFValidator(func(r *http.Request, token string) error {
+		if token != "secret" {
+			return errMismatch
+		}
+
+		return nil
+	}))
+
+	type Req struct {
+		Token string `csrf:"X-CSRF-Token"`
+	}
+
+	// header
+	var req Req
+
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-CSRF-Token", "secret")
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.Token != "secret" {
+		t.Errorf(`want "secret", got "%s"`, req.Token)
+	}
+
+	// form field
+	r = httptest.NewRequest(http.MethodPost, "/", strings.NewReader("X-CSRF-Token=secret"))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	if err := dec.Decode(r, &Req{}); err != nil {
+		t.Error(err)
+	}
+
+	// invalid
+	r = httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-CSRF-Token", "guess")
+
+	if err := dec.Decode(r, &Req{}); !errors.Is(err, ErrInvalidCSRFToken) || !errors.Is(err, errMismatch) {
+		t.Errorf("want ErrInvalidCSRFToken, got %v", err)
+	}
+
+	// no validator
+	r = httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-CSRF-Token", "secret")
+
+	if err := Decode(r, &Req{}); !errors.Is(err, ErrInvalidCSRFToken) {
+		t.Errorf("want ErrInvalidCSRFToken, got %v", err)
+	}
+}
+
+func TestDecoder_DecodePath(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder()
+
+	err := quick.Check(func(id int) bool {
+		var req struct {
+			ClientID int `path:"id"`
+		}
+
+		// Path has no impact on the test. Set path value manually.
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.SetPathValue("id", strconv.Itoa(id))
+
+		if err := dec.Decode(r, &req); err != nil {
+			t.Log(err)
+			return false
+		}
+
+		return id == req.ClientID
+	}, nil)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDecoder_DecodeOnDecodeDuration(t *testing.T) {
+	t.Parallel()
+
+	var (
+		called   bool
+		duration time.Duration
+	)
+
+	dec := NewDecoder(OnDecodeDuration(func(d time.Duration) {
+		called = true
+		duration = d
+	}))
+
+	var req struct {
+		Body struct {
+			ID int
+		} `body:"json"`
+	}
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"id":1}`))
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if !called {
+		t.Fatal("want callback called, got not called")
+	}
+
+	// the clock resolution may report zero
+	if duration < 0 {
+		t.Errorf("want non-negative duration, got %s", duration)
+	}
+
+	// map target
+	called = false
+
+	var query map[string]string
+
+	if err := dec.Decode(httptest.NewRequest(http.MethodGet, "/?id=1", nil), &query); err != nil {
+		t.Error(err)
+	}
+
+	if !called {
+		t.Error("want callback called for map target, got not called")
+	}
+}
+
+func TestDecoder_DecodePathSlice(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		IDs []int `path:"ids"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetPathValue("ids", "3,4,5")
+
+	if err := NewDecoder().Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if want := []int{3, 4, 5}; !slices.Equal(want, req.IDs) {
+		t.Errorf("want %v, got %v", want, req.IDs)
+	}
+}
+
+func TestDecoder_DecodePathSplitter(t *testing.T) {
+	t.Parallel()
+
+	dec := NewDecoder(PathSplitter(func(raw string) []string {
+		return strings.Split(strings.Trim(raw, "/"), "/")
+	}))
+
+	var req struct {
+		Segments []string `path:"path"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetPathValue("path", "docs/api/v1/")
+
+	if err := dec.Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if want := []string{"docs", "api", "v1"}; !slices.Equal(want, req.Segments) {
+		t.Errorf("want %v, got %v", want, req.Segments)
+	}
+}
+
+func TestDecoder_DecodePathStyles(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		tag, value string
+		want       []int
+	}{
+		{tag: `path:"id"`, value: "3,4,5", want: []int{3, 4, 5}},
+		{tag: `path:"id,label"`, value: ".3,4,5", want: []int{3, 4, 5}},
+		{tag: `path:"id,label,explode"`, value: ".3.4.5", want: []int{3, 4, 5}},
+		{tag: `path:"id,matrix"`, value: ";id=3,4,5", want: []int{3, 4, 5}},
+		{tag: `path:"id,matrix,explode"`, value: ";id=3;id=4;id=5", want: []int{3, 4, 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tag, func(t *testing.T) {
+			t.Parallel()
+
+			v := reflect.New(reflect.StructOf([]reflect.StructField{
+				{Name: "ID", Type: reflect.TypeFor[[]int](), Tag: reflect.StructTag(test.tag)},
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.SetPathValue("id", test.value)
+
+			if err := Decode(r, v.Interface()); err != nil {
+				t.Fatal(err)
+			}
+
+			if got := v.Elem().Field(0).Interface().([]int); !slices.Equal(test.want, got) { //nolint:forcetypeassert
+				t.Errorf("want %v, got %v", test.want, got)
+			}
+		})
+	}
+
+	var req struct {
+		Label  int `path:"label,label"`
+		Matrix int `path:"matrix,matrix"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetPathValue("label", ".7")
+	r.SetPathValue("matrix", ";matrix=8")
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if req.Label != 7 || req.Matrix != 8 {
+		t.Errorf("want 7 and 8, got %d and %d", req.Label, req.Matrix)
+	}
+
+	r.SetPathValue("matrix", ";id=8")
+
+	want := `path 'matrix': want matrix style parameter "matrix", got "id"`
+	if err := Decode(r, &req); err == nil || err.Error() != want {
+		t.Errorf(`want "%s", got "%s"`, want, err)
+	}
+}
+
+func TestDecoder_DecodePathTime(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		From time.Time `path:"from"`
+		Day  time.Time `path:"day,format=2006-01-02"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.SetPathValue("from", "2024-03-01T10:00:00Z")
+	r.SetPathValue("day", "2024-03-02")
+
+	if err := Decode(r, &req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC); !req.From.Equal(want) {
+		t.Errorf("want %s, got %s", want, req.From)
+	}
+
+	if want := time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC); !req.Day.Equal(want) {
+		t.Errorf("want %s, got %s", want, req.Day)
+	}
+}
+
+func TestDecoder_DecodeMeta(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		ID   int  `path:"id"`
+		Age  int  `query:"age"`
+		Meta Meta `oas:",meta"`
+	}
+
+	var metaTag struct {
+		Meta *Meta `meta:""`
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		if err := NewDecoder(CollectErrors()).Decode(r, &req); err == nil {
+			t.Error("want error, got no error")
+		}
+
+		if err := Decode(r, &metaTag); err != nil {
+			t.Error(err)
+		}
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/users/1?age=old", nil)
+	r.Header.Set("Content-Type", "application/json")
+
+	mux.ServeHTTP(httptest.NewRecorder(), r)
+
+	want := Meta{
+		Method:      http.MethodPost,
+		Path:        "/users/1",
+		Pattern:     "POST /users/{id}",
+		ContentType: "application/json",
+		Errors:      1,
+	}
+
+	if want != req.Meta {
+		t.Errorf("want %+v, got %+v", want, req.Meta)
+	}
+
+	want.Errors = 0
+
+	if metaTag.Meta == nil || want != *metaTag.Meta {
+		t.Errorf("want %+v, got %+v", want, metaTag.Meta)
+	}
+}
+
+func TestDecoder_DecodeAllowOrigins(t *testing.T) {
+	t.Parallel()
+
+	var req struct {
+		ID   int `path:"id"`
+		Age  int `query:"age"`
+		Body struct {
+			Name string
+		} `body:"json"`
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/?age=7", strings.NewReader(`{"name":"alex"}`))
+	r.SetPathValue("id", "1")
+
+	if err := NewDecoder(AllowOrigins("path", "query")).Decode(r, &req); err != nil {
+		t.Error(err)
+	}
+
+	if req.ID != 1 || req.Age != 7 {
+		t.Errorf("want id 1 and age 7, got id %d and age %d", req.ID, req.Age)
+	}
+
+	if req.Body.Name != "" {
+		t.Errorf("want empty body, got %+v", req.Body)
+	}
+}
+
+func TestDecodeEmbeddedStructs(t *testing.T) {
+	t.Parallel()
+
+	type Range struct {
+		Start int `query:"rangeStart"`
+		End   int `query:"rangeEnd"`
+	}
+
+	err := quick.Check(func(rangeStart, rangeEnd int) bool {
+		query := make(url.Values)
+		query.Set("rangeStart", strconv.Itoa(rangeStart))
+		query.Set("rangeEnd", strconv.Itoa(rangeEnd))
+		query.Set("sort", "name")
+
+		r := httptest.NewRequest(http.MethodGet, "/?"+query.Encode(), nil)
+
+		var req struct {
+			Sort
+			Range
+		}
+
+		if err := Decode(r, &req); err != nil {
+			t.Log(err)
+			return false
+		}
+
+		return rangeStart == req.Range.Start &&
+			rangeEnd == req.Range.End &&
 			req.Sort.Name == "name" &&
 			req.Sort.Asc
 	}, nil)
@@ -573,6 +4745,43 @@ func TestDecodeImplodeLastValue(t *testing.T) {
 	}
 }
 
+func TestDecoder_DecodeConcurrent(t *testing.T) {
+	t.Parallel()
+
+	type Req struct {
+		ID   int    `path:"id"`
+		Name string `query:"name,required"`
+		Auth string `header:"Authorization"`
+	}
+
+	dec := NewDecoder()
+
+	var wg sync.WaitGroup
+
+	for i := range 16 {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			r := httptest.NewRequest(http.MethodGet, "/?name=alex", nil)
+			r.SetPathValue("id", strconv.Itoa(i))
+
+			var req Req
+
+			if err := dec.Decode(r, &req); err != nil {
+				t.Error(err)
+			}
+
+			if req.ID != i || req.Name != "alex" {
+				t.Errorf("want %d alex, got %d %s", i, req.ID, req.Name)
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
 func BenchmarkDecode(b *testing.B) {
 	var err error
 
@@ -583,6 +4792,8 @@ func BenchmarkDecode(b *testing.B) {
 
 	r := httptest.NewRequest(http.MethodGet, "/?value=one,two,three&deep[ok]=1", nil)
 
+	b.ReportAllocs()
+
 	for range b.N {
 		err = Decode(r, &req)
 	}
@@ -0,0 +1,9 @@
+module go.expect.digital/request/routers
+
+go 1.23
+
+require (
+	github.com/go-chi/chi/v5 v5.3.1
+	github.com/gorilla/mux v1.8.1
+	go.expect.digital/request v0.0.0-20261016150700-b6bf3c8a444d
+)
@@ -0,0 +1,23 @@
+// Package routers provides the path parameter options of [go.expect.digital/request] for third-party routers.
+// The package is a separate module, so that the request module does not depend on the routers.
+package routers
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/mux"
+	"go.expect.digital/request"
+)
+
+// ChiPathValue returns the option reading path parameters of [github.com/go-chi/chi/v5] router:
+//
+//	decoder := request.NewDecoder(routers.ChiPathValue())
+func ChiPathValue() request.Opt { //nolint:ireturn
+	return request.PathValue(chi.URLParam)
+}
+
+// GorillaPathValue returns the option reading path parameters of [github.com/gorilla/mux] router:
+//
+//	decoder := request.NewDecoder(routers.GorillaPathValue())
+func GorillaPathValue() request.Opt { //nolint:ireturn
+	return request.PathVars(mux.Vars)
+}
@@ -0,0 +1,58 @@
+package routers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/mux"
+	"go.expect.digital/request"
+)
+
+type getUserRequest struct {
+	ID   int    `path:"id"`
+	Slug string `path:"slug"`
+}
+
+func TestChiPathValue(t *testing.T) {
+	t.Parallel()
+
+	var req getUserRequest
+
+	decoder := request.NewDecoder(ChiPathValue())
+
+	r := chi.NewRouter()
+	r.Get("/users/{id}/{slug}", func(w http.ResponseWriter, r *http.Request) {
+		if err := decoder.Decode(r, &req); err != nil {
+			t.Error(err)
+		}
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1/alex", nil))
+
+	if want := (getUserRequest{ID: 1, Slug: "alex"}); want != req {
+		t.Errorf("want %+v, got %+v", want, req)
+	}
+}
+
+func TestGorillaPathValue(t *testing.T) {
+	t.Parallel()
+
+	var req getUserRequest
+
+	decoder := request.NewDecoder(GorillaPathValue())
+
+	r := mux.NewRouter()
+	r.Path("/users/{id}/{slug}").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := decoder.Decode(r, &req); err != nil {
+			t.Error(err)
+		}
+	})
+
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/1/alex", nil))
+
+	if want := (getUserRequest{ID: 1, Slug: "alex"}); want != req {
+		t.Errorf("want %+v, got %+v", want, req)
+	}
+}